@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"protoc-gen-scip/partial"
+)
+
+type callgraphFlags struct {
+	from   string
+	to     string
+	format string
+}
+
+func callgraphCommand() cli.Command {
+	var flags callgraphFlags
+	callgraph := cli.Command{
+		Name:  "callgraph",
+		Usage: "Extract the RPC call graph of a SCIP index generated by protoc-gen-scip",
+		Flags: []cli.Flag{
+			fromFlag(&flags.from),
+			&cli.StringFlag{
+				Name:        "to",
+				Usage:       "Output path for the call graph",
+				Destination: &flags.to,
+				Value:       "-",
+			},
+			&cli.StringFlag{
+				Name:        "format",
+				Usage:       "Output format, json or dot",
+				Destination: &flags.format,
+				Value:       "json",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			return callgraphMain(flags)
+		},
+	}
+	return callgraph
+}
+
+func callgraphMain(flags callgraphFlags) error {
+	if flags.format != "json" && flags.format != "dot" {
+		return errors.Newf("expected json or dot format but found %s", flags.format)
+	}
+	scipIndex, err := readFromOption(flags.from)
+	if err != nil {
+		return err
+	}
+
+	var graphWriter io.Writer
+	if flags.to == "-" {
+		graphWriter = os.Stdout
+	} else {
+		graphFile, err := os.Create(flags.to)
+		if err != nil {
+			return err
+		}
+		defer graphFile.Close()
+		graphWriter = graphFile
+	}
+
+	graph := partial.BuildCallGraph(scipIndex)
+	if flags.format == "dot" {
+		err = graph.WriteDOT(graphWriter)
+	} else {
+		encoder := json.NewEncoder(graphWriter)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(graph)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to write call graph to path %s", flags.to)
+	}
+	return nil
+}
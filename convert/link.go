@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"github.com/sourcegraph/sourcegraph/lib/errors"
+
+	"protoc-gen-scip/partial"
+)
+
+type linkFlags struct {
+	protoRoot     string
+	descriptorSet string
+	out           string
+	params        partial.Params
+	matchers      cli.StringSlice
+	scipDirs      cli.StringSlice
+	include       cli.StringSlice
+	exclude       cli.StringSlice
+}
+
+func linkCommand() cli.Command {
+	var flags linkFlags
+	link := cli.Command{
+		Name:      "link",
+		Usage:     "Link SCIP indexes with proto files without protoc",
+		ArgsUsage: "[proto files relative to the proto root or in the descriptor set]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "proto-root",
+				Usage:       "Import path of the proto files, which their paths in the linked index are relative to; all the proto files under it are linked if none is given",
+				Destination: &flags.protoRoot,
+				Value:       ".",
+			},
+			&cli.StringFlag{
+				Name:        "descriptor-set",
+				Usage:       "FileDescriptorSet or buf image to link instead of compiling the proto files under the proto root",
+				Destination: &flags.descriptorSet,
+			},
+			&cli.StringSliceFlag{
+				Name:        "scip-dir",
+				Usage:       "Directories that contain the generated scip indexes, searched recursively, the working directory by default",
+				Destination: &flags.scipDirs,
+			},
+			&cli.StringSliceFlag{
+				Name:        "include",
+				Usage:       "Globs of the scip indexes to link, relative to their scip-dir",
+				Destination: &flags.include,
+			},
+			&cli.StringSliceFlag{
+				Name:        "exclude",
+				Usage:       "Globs of the scip indexes to skip, relative to their scip-dir",
+				Destination: &flags.exclude,
+			},
+			&cli.StringFlag{
+				Name:        "out",
+				Usage:       "Output path for the linked index",
+				Destination: &flags.out,
+				Value:       "out.scip",
+			},
+			&cli.StringFlag{
+				Name:        "sourceroot",
+				Usage:       "ABSOLUTE source root in the unified output index",
+				Destination: &flags.params.SourceRoot,
+			},
+			&cli.StringSliceFlag{
+				Name:        "matcher",
+				Usage:       "Matchers used to link services, one of " + strings.Join(partial.MatcherNames(), ", "),
+				Destination: &flags.matchers,
+			},
+			&cli.BoolFlag{
+				Name:        "fuzzy",
+				Usage:       "Fall back to fuzzy name matching for the services that could not be linked",
+				Destination: &flags.params.Fuzzy,
+			},
+			&cli.BoolFlag{
+				Name:        "unscoped",
+				Usage:       "Fall back to matching the types outside of the generated packages for the services that could not be linked",
+				Destination: &flags.params.Unscoped,
+			},
+			&cli.StringFlag{
+				Name:        "mapping",
+				Usage:       "YAML file declaring links in addition to the matched ones",
+				Destination: &flags.params.MappingFile,
+			},
+			&cli.StringFlag{
+				Name:        "mode",
+				Usage:       "partial to keep the linked symbols only, or full to keep every document and external symbol",
+				Destination: &flags.params.Mode,
+				Value:       "partial",
+			},
+			&cli.StringFlag{
+				Name:        "rewrite",
+				Usage:       "How the symbols of the indexes are made unique: namespace, package, version or none",
+				Destination: &flags.params.Rewrite,
+				Value:       "namespace",
+			},
+			&cli.StringFlag{
+				Name:        "encoding",
+				Usage:       "utf8 or utf16 to convert the ranges of the indexes to a single text encoding",
+				Destination: &flags.params.Encoding,
+			},
+			&cli.StringFlag{
+				Name:        "manifest",
+				Usage:       "YAML file giving the name, root and namespace of the indexes",
+				Destination: &flags.params.ManifestFile,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			return linkMain(c.Context, flags, c.Args().Slice())
+		},
+	}
+	return link
+}
+
+func linkMain(ctx context.Context, flags linkFlags, files []string) error {
+	// glog logs the errors of the plugin to stderr, as protoc does.
+	flag.Set("logtostderr", "false")
+	flag.Set("stderrthreshold", "ERROR")
+	flag.CommandLine.Parse(nil)
+
+	req, err := linkRequest(ctx, flags, files)
+	if err != nil {
+		return err
+	}
+	flags.params.OutFile = filepath.Base(flags.out)
+	flags.params.ProtoRoot = flags.protoRoot
+	flags.params.Matchers = flags.matchers.Value()
+	flags.params.ScipDirs = flags.scipDirs.Value()
+	flags.params.Include = flags.include.Value()
+	flags.params.Exclude = flags.exclude.Value()
+	resp, err := partial.Link(req, flags.params)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return errors.New(resp.GetError())
+	}
+	// the plugin only logs the lack of index, so that protoc still succeeds.
+	if len(resp.File) == 0 {
+		return errors.Newf("no index to be linked in %s", strings.Join(flags.params.ScipDirs, ", "))
+	}
+	for _, f := range resp.File {
+		if err := os.WriteFile(flags.out, []byte(f.GetContent()), 0666); err != nil {
+			return errors.Wrapf(err, "failed to write the linked index to path %s", flags.out)
+		}
+	}
+	glog.Flush()
+	return nil
+}
+
+// linkRequest builds the request of the plugin from the descriptor set, or by
+// compiling the proto files.
+func linkRequest(ctx context.Context, flags linkFlags, files []string) (*pluginpb.CodeGeneratorRequest, error) {
+	if flags.descriptorSet != "" {
+		req, err := partial.DescriptorSetRequest(flags.descriptorSet, files)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the descriptor set")
+		}
+		return req, nil
+	}
+
+	if len(files) == 0 {
+		var err error
+		if files, err = findProtoFiles(flags.protoRoot); err != nil {
+			return nil, err
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.Newf("no proto file found in %s", flags.protoRoot)
+	}
+	req, err := partial.CompileRequest(ctx, []string{flags.protoRoot}, files)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compile the proto files")
+	}
+	return req, nil
+}
+
+// findProtoFiles returns the proto files under root, relative to it.
+func findProtoFiles(root string) ([]string, error) {
+	files := []string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".proto" {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
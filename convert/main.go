@@ -309,7 +309,9 @@ func commands() []*cli.Command {
 	convert := convertCommand()
 	cloccmd := clocCommand()
 	tomem := tomemgraph()
-	return []*cli.Command{&convert, &cloccmd, &tomem}
+	callgraph := callgraphCommand()
+	link := linkCommand()
+	return []*cli.Command{&convert, &cloccmd, &tomem, &callgraph, &link}
 }
 func main() {
 	app := scipApp()
@@ -3,19 +3,56 @@ package main
 import (
 	"flag"
 	"fmt"
-	"path/filepath"
 	"protoc-gen-scip/partial"
+	"strings"
 
-	"github.com/golang/glog"
 	"google.golang.org/protobuf/compiler/protogen"
-	"google.golang.org/protobuf/types/pluginpb"
 )
 
-const version = "0.0.1"
+const version = partial.Version
 
-var scipFilePath *string
+var scipDirs listFlag
+var includeGlobs listFlag
+var excludeGlobs listFlag
 var outputFile *string
 var sourceroot *string
+var matcherNames listFlag
+var fuzzy *bool
+var unscoped *bool
+var mappingFile *string
+var manifestFile *string
+var mode *string
+var rewrite *string
+var encoding *string
+
+// listFlag is a flag that can be given several times. As protoc splits the
+// plugin parameters on commas, the values following a listFlag without a name
+// (e.g. matcher=go-grpc,python-grpc) are appended to it, see paramFunc.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// paramFunc sets the plugin parameters on flags.
+func paramFunc(flags *flag.FlagSet) func(name, value string) error {
+	var last *listFlag
+	return func(name, value string) error {
+		if value == "" && flags.Lookup(name) == nil && last != nil {
+			return last.Set(name)
+		}
+		if err := flags.Set(name, value); err != nil {
+			return err
+		}
+		last, _ = flags.Lookup(name).Value.(*listFlag)
+		return nil
+	}
+}
 
 func init() {
 	flag.Set("logtostderr", "false")
@@ -32,37 +69,37 @@ func main() {
 	}
 
 	var flags flag.FlagSet
-	scipFilePath = flags.String("scip_dir", "", "specify the directory that contains the generated scip indexes")
+	flags.Var(&scipDirs, "scip_dir", "specify the directories that contain the generated scip indexes, searched recursively, the working directory by default")
+	flags.Var(&includeGlobs, "include", "specify the globs of the scip indexes to link, relative to their scip_dir")
+	flags.Var(&excludeGlobs, "exclude", "specify the globs of the scip indexes to skip, relative to their scip_dir")
 	outputFile = flags.String("out_file", "out.scip", "specify the file to the newly updated scip")
 	sourceroot = flags.String("sourceroot", "", "specify the ABSOLUTE source root in the unified output index")
+	fuzzy = flags.Bool("fuzzy", false, "fall back to fuzzy name matching for the services that could not be linked")
+	unscoped = flags.Bool("unscoped", false, "fall back to matching the types outside of the generated packages for the services that could not be linked")
+	mappingFile = flags.String("mapping", "", "specify a YAML file declaring links in addition to the matched ones")
+	mode = flags.String("mode", "partial", "specify partial to keep the linked symbols only, or full to keep every document and external symbol")
+	rewrite = flags.String("rewrite", "namespace", "specify how the symbols of the indexes are made unique: namespace, package, version or none")
+	encoding = flags.String("encoding", "", "specify utf8 or utf16 to convert the ranges of the indexes to a single text encoding")
+	manifestFile = flags.String("manifest", "", "specify a YAML file giving the name, root and namespace of the indexes")
+	flags.Var(&matcherNames, "matcher", "specify the matchers used to link services, one of "+strings.Join(partial.MatcherNames(), ", "))
 
 	protogen.Options{
-		ParamFunc: flags.Set,
+		ParamFunc: paramFunc(&flags),
 	}.Run(func(gen *protogen.Plugin) error {
-		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
-		inputFiles := []*protogen.File{}
-		for _, f := range gen.Files {
-			if !f.Generate {
-				continue
-			}
-			inputFiles = append(inputFiles, f)
-		}
-
-		scipFiles, err := filepath.Glob(filepath.Join(*scipFilePath, "*.scip"))
-
-		if err != nil {
-			glog.Fatalf("failed to scan the directory for scip index: %v", err)
-		}
-		if len(scipFiles) == 0 {
-			glog.Errorf("no index to be analyzed")
-			return nil
-		}
-
-		if !filepath.IsAbs(*sourceroot) {
-			glog.Error("the source root is not an absolute path")
-			*sourceroot = ""
-		}
-		partial.GenerateFile(gen, inputFiles, scipFiles, *outputFile, *sourceroot)
-		return nil
+		return partial.Generate(gen, partial.Params{
+			ScipDirs:     scipDirs,
+			Include:      includeGlobs,
+			Exclude:      excludeGlobs,
+			OutFile:      *outputFile,
+			SourceRoot:   *sourceroot,
+			Matchers:     matcherNames,
+			Fuzzy:        *fuzzy,
+			Unscoped:     *unscoped,
+			MappingFile:  *mappingFile,
+			ManifestFile: *manifestFile,
+			Mode:         *mode,
+			Rewrite:      *rewrite,
+			Encoding:     *encoding,
+		})
 	})
 }
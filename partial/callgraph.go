@@ -0,0 +1,271 @@
+package partial
+
+import (
+	"fmt"
+	"io"
+	"protoc-gen-scip/scip"
+	"sort"
+	"strings"
+)
+
+// CallGraph holds the RPC calls found in a merged index.
+type CallGraph struct {
+	Edges []*CallEdge `json:"edges"`
+}
+
+// CallEdge is a call from a client to the implementation of a proto method.
+// The callee is nil when no implementation of the method is indexed.
+type CallEdge struct {
+	Caller *CallNode `json:"caller"`
+	Method string    `json:"method"`
+	Callee *CallNode `json:"callee"`
+}
+
+// CallNode is a symbol on either side of a call. The caller symbol is empty
+// when the call is not made inside a known function.
+type CallNode struct {
+	Symbol   string `json:"symbol"`
+	Document string `json:"document"`
+	Project  string `json:"project"`
+}
+
+func (n *CallNode) key() string {
+	if n == nil {
+		return ""
+	}
+	return n.Project + " " + n.Document + " " + n.Symbol
+}
+
+// BuildCallGraph extracts the RPC calls from an index generated by the
+// plugin. The callers are the call sites linked to the proto methods, and
+// the callees are the symbols implementing them, directly or through the
+// generated interfaces. The generated code, whose relationships to the proto
+// methods are definitions, and the client stubs, which only reference them,
+// are not callees. Stream wrappers count as their method.
+func BuildCallGraph(index *scip.Index) *CallGraph {
+	methods := map[string]string{}
+	for _, d := range index.Documents {
+		if !isProtoDocument(d) {
+			continue
+		}
+		for _, si := range d.Symbols {
+			if si.Kind == scip.SymbolInformation_Method {
+				if method := rpcMethod(si.Symbol); method != "" {
+					methods[si.Symbol] = method
+				}
+			}
+		}
+	}
+
+	callers := map[string][]*CallNode{}
+	for _, d := range index.Documents {
+		if isProtoDocument(d) {
+			continue
+		}
+		for _, o := range d.Occurrences {
+			method, ok := methods[o.Symbol]
+			if !ok || o.SymbolRoles&int32(scip.SymbolRole_Definition) != 0 {
+				continue
+			}
+			caller := &CallNode{Symbol: enclosingFunction(d, o), Document: d.RelativePath}
+			caller.Project = documentProject(d, caller.Symbol, methods)
+			callers[method] = append(callers[method], caller)
+		}
+	}
+
+	implemented := map[string]string{}
+	for symbol, method := range methods {
+		implemented[symbol] = method
+	}
+	callees := map[string][]*CallNode{}
+	for prevSize := -1; prevSize != len(implemented); {
+		prevSize = len(implemented)
+		for _, d := range index.Documents {
+			if isProtoDocument(d) {
+				continue
+			}
+			for _, si := range d.Symbols {
+				if _, ok := implemented[si.Symbol]; ok {
+					continue
+				}
+				for _, rel := range si.Relationships {
+					method, ok := implemented[rel.Symbol]
+					if !ok || !rel.IsImplementation {
+						continue
+					}
+					implemented[si.Symbol] = method
+					if isGenerated(si, methods) {
+						break
+					}
+					callees[method] = append(callees[method], &CallNode{
+						Symbol:   si.Symbol,
+						Document: d.RelativePath,
+						Project:  symbolProject(si.Symbol, d),
+					})
+					break
+				}
+			}
+		}
+	}
+
+	g := &CallGraph{}
+	seen := map[string]struct{}{}
+	for method, from := range callers {
+		to := callees[method]
+		if len(to) == 0 {
+			to = []*CallNode{nil}
+		}
+		for _, caller := range from {
+			for _, callee := range to {
+				key := caller.key() + "\x00" + method + "\x00" + callee.key()
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				g.Edges = append(g.Edges, &CallEdge{Caller: caller, Method: method, Callee: callee})
+			}
+		}
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		if a.Caller.key() != b.Caller.key() {
+			return a.Caller.key() < b.Caller.key()
+		}
+		return a.Callee.key() < b.Callee.key()
+	})
+	return g
+}
+
+// WriteDOT writes the graph in the DOT language, with a node per caller,
+// proto method and callee.
+func (g *CallGraph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph callgraph {\n")
+	nodes := map[string]string{}
+	node := func(key, label, shape string) string {
+		if id, ok := nodes[key]; ok {
+			return id
+		}
+		id := fmt.Sprintf("n%d", len(nodes))
+		nodes[key] = id
+		fmt.Fprintf(&b, "  %s [label=%q, shape=%s];\n", id, label, shape)
+		return id
+	}
+	edges := map[string]struct{}{}
+	edge := func(from, to string) {
+		if _, ok := edges[from+to]; !ok {
+			edges[from+to] = struct{}{}
+			fmt.Fprintf(&b, "  %s -> %s;\n", from, to)
+		}
+	}
+	for _, e := range g.Edges {
+		caller := node(e.Caller.key(), e.Caller.label(), "box")
+		method := node(e.Method, e.Method, "ellipse")
+		edge(caller, method)
+		if e.Callee != nil {
+			edge(method, node(e.Callee.key(), e.Callee.label(), "box"))
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (n *CallNode) label() string {
+	symbol := n.Symbol
+	if symbol == "" {
+		symbol = n.Document
+	}
+	return n.Project + "\n" + symbol
+}
+
+// isGenerated reports whether the symbol was generated for a proto method,
+// i.e. is defined by it.
+func isGenerated(si *scip.SymbolInformation, methods map[string]string) bool {
+	for _, rel := range si.Relationships {
+		if _, ok := methods[rel.Symbol]; ok && rel.IsDefinition {
+			return true
+		}
+	}
+	return false
+}
+
+func isProtoDocument(d *scip.Document) bool {
+	return strings.HasSuffix(d.RelativePath, ".proto")
+}
+
+// rpcMethod returns the symbol of the method a proto method or stream symbol
+// belongs to.
+func rpcMethod(symbol string) string {
+	sym, err := scip.ParseSymbol(symbol)
+	if err != nil {
+		return ""
+	}
+	for i, desc := range sym.Descriptors {
+		if desc.Suffix == scip.Descriptor_Term && i > 0 && sym.Descriptors[i-1].Suffix == scip.Descriptor_Type {
+			sym.Descriptors = sym.Descriptors[:i+1]
+			return scip.VerboseSymbolFormatter.FormatSymbol(sym)
+		}
+	}
+	return ""
+}
+
+// enclosingFunction returns the function or method defined around the
+// occurrence, preferring enclosing ranges over the closest preceding
+// definition.
+func enclosingFunction(d *scip.Document, o *scip.Occurrence) string {
+	line := o.Range[0]
+	symbol, start := "", int32(-1)
+	for _, def := range d.Occurrences {
+		if def.SymbolRoles&int32(scip.SymbolRole_Definition) == 0 || !strings.HasSuffix(def.Symbol, ").") {
+			continue
+		}
+		if r := def.EnclosingRange; len(r) >= 3 {
+			end := r[2]
+			if len(r) == 3 {
+				end = r[0]
+			}
+			if r[0] <= line && line <= end && r[0] >= start {
+				symbol, start = def.Symbol, r[0]
+			}
+			continue
+		}
+		if def.Range[0] <= line && def.Range[0] >= start {
+			symbol, start = def.Symbol, def.Range[0]
+		}
+	}
+	return symbol
+}
+
+// documentProject returns the project of the symbol, or the one of the
+// first symbol of the document which is not a proto method.
+func documentProject(d *scip.Document, symbol string, methods map[string]string) string {
+	if symbol != "" {
+		return symbolProject(symbol, d)
+	}
+	for _, o := range d.Occurrences {
+		if _, ok := methods[o.Symbol]; !ok && !scip.IsLocalSymbol(o.Symbol) {
+			return symbolProject(o.Symbol, d)
+		}
+	}
+	return d.RelativePath
+}
+
+// symbolProject returns the package name of the symbol, or its namespace
+// prefix when the indexer left the name empty.
+func symbolProject(symbol string, d *scip.Document) string {
+	parts := strings.SplitN(symbol, " ", 5)
+	if len(parts) < 5 {
+		return d.RelativePath
+	}
+	if name := parts[2]; name != "" && name != "." {
+		return name
+	}
+	if namespace, _, ok := strings.Cut(parts[4], "/"); ok {
+		return namespace
+	}
+	return parts[0]
+}
@@ -0,0 +1,96 @@
+package partial
+
+import (
+	"bytes"
+	"protoc-gen-scip/scip"
+	"strings"
+	"testing"
+)
+
+func TestBuildCallGraph(t *testing.T) {
+	const (
+		protoMethod = "scip-proto proto protos proto3 proto/Go_A/Go_A#Go_A_1."
+		protoStream = "scip-proto proto protos proto3 proto/Go_A/Go_A#Go_A_1.Server#Send."
+		protoOther  = "scip-proto proto protos proto3 proto/Go_A/Go_A#Go_A_2."
+		iface       = "scip-go gomod Go_A cb6b82253d24 Go_A/proto/Go_AServer#Go_A_1."
+		impl        = "scip-go gomod Go_A cb6b82253d24 Go_A/cmd/server#Go_A_1()."
+		sender      = "scip-go gomod Go_A cb6b82253d24 Go_A/proto/Go_A_Go_A_1Server#Send."
+		stub        = "scip-python python Py_A 0.1 `protos.Go_A_pb2_grpc`/Go_AStub#Go_A_1."
+		caller      = "scip-python python Py_A 0.1 client/run()."
+	)
+	index := &scip.Index{Documents: []*scip.Document{
+		{
+			RelativePath: "protos/Go_A.proto",
+			Symbols: []*scip.SymbolInformation{
+				{Symbol: protoMethod, Kind: scip.SymbolInformation_Method},
+				{Symbol: protoStream, Kind: scip.SymbolInformation_Method},
+				{Symbol: protoOther, Kind: scip.SymbolInformation_Method},
+			},
+		},
+		{
+			RelativePath: "Go_A/server.go",
+			Symbols: []*scip.SymbolInformation{
+				{Symbol: iface, Relationships: []*scip.Relationship{{Symbol: protoMethod, IsImplementation: true, IsDefinition: true}}},
+				{Symbol: impl, Relationships: []*scip.Relationship{{Symbol: iface, IsImplementation: true}}},
+				{Symbol: sender, Relationships: []*scip.Relationship{{Symbol: protoStream, IsImplementation: true, IsDefinition: true}}},
+			},
+		},
+		{
+			RelativePath: "Py_A/client.py",
+			Symbols: []*scip.SymbolInformation{
+				{Symbol: stub, Relationships: []*scip.Relationship{{Symbol: protoMethod, IsReference: true}}},
+			},
+			Occurrences: []*scip.Occurrence{
+				{Range: []int32{3, 4, 7}, Symbol: caller, SymbolRoles: int32(scip.SymbolRole_Definition)},
+				{Range: []int32{5, 8, 14}, Symbol: stub},
+				{Range: []int32{5, 8, 14}, Symbol: protoMethod},
+				{Range: []int32{6, 8, 14}, Symbol: protoMethod},
+				{Range: []int32{7, 8, 14}, Symbol: protoOther},
+			},
+		},
+	}}
+
+	g := BuildCallGraph(index)
+	callees := map[string][]string{}
+	for _, e := range g.Edges {
+		if e.Caller.Symbol != caller || e.Caller.Project != "Py_A" || e.Caller.Document != "Py_A/client.py" {
+			t.Errorf("unexpected caller %+v", e.Caller)
+		}
+		callee := ""
+		if e.Callee != nil {
+			callee = e.Callee.Symbol
+		}
+		callees[e.Method] = append(callees[e.Method], callee)
+	}
+	if len(callees) != 2 || strings.Join(callees[protoMethod], " ") != impl {
+		t.Errorf("expected the server implementation to be the only callee, got %v", callees)
+	}
+	if len(callees[protoOther]) != 1 || callees[protoOther][0] != "" {
+		t.Errorf("expected a call without callee for the unimplemented method, got %v", callees[protoOther])
+	}
+
+	var b bytes.Buffer
+	if err := g.WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(b.String(), "->") != 3 {
+		t.Errorf("expected two caller edges and a callee edge, got:\n%s", b.String())
+	}
+}
+
+func TestBuildCallGraphOfGeneratedIndex(t *testing.T) {
+	index := unmarshalIndex(t, linkTestdata(t, compileTestdata(t), testdataParams()))
+	g := BuildCallGraph(index)
+	if len(g.Edges) == 0 {
+		t.Fatal("expected the calls of the Python client")
+	}
+	for _, e := range g.Edges {
+		if e.Caller.Symbol == "" || e.Caller.Project != "Python_A" {
+			t.Errorf("expected the caller to be kept in the partial index, got %+v", e.Caller)
+		}
+		// Ts_A is only served by generated code in the testdata.
+		if e.Callee != nil {
+			t.Errorf("expected no implementation of %s, got %s", e.Method, e.Callee.Symbol)
+		}
+	}
+}
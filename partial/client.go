@@ -0,0 +1,82 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"sync"
+
+	"github.com/golang/glog"
+)
+
+// clientSymbols maps the method symbols of matched client stubs to the
+// symbols of the proto methods they call. The stub symbols are the original
+// ones, as the indexes calling them may not be the one defining them, and so
+// rewrite them with another namespace.
+var clientSymbols sync.Map
+
+// addClientSymbols records the method symbols of a matched client stub or
+// client stream. They call the proto methods rather than implement them, so
+// their relationships to the proto methods are kept as plain references.
+func addClientSymbols(res *MatchResult, siMap map[*scip.SymbolInformation]string, symbols map[string]*scip.SymbolInformation) {
+	if !res.Client {
+		return
+	}
+	methods := []*scip.SymbolInformation{}
+	for _, matches := range res.Methods {
+		methods = append(methods, matches...)
+	}
+	for _, stream := range res.Streams {
+		for _, matches := range stream.Methods {
+			methods = append(methods, matches...)
+		}
+	}
+	for _, si := range methods {
+		if key, ok := siMap[si]; ok {
+			original := si.Symbol
+			if s, ok := originalSymbols.Load(si.Symbol); ok {
+				original = s.(string)
+			}
+			clientSymbols.Store(original, symbols[key].Symbol)
+			for _, rel := range si.Relationships {
+				if rel.Symbol == symbols[key].Symbol {
+					rel.IsImplementation = false
+				}
+			}
+		}
+	}
+}
+
+// linkClientCalls keeps the call sites of client stub methods in the indexed
+// documents and adds a reference to the called proto method at each of them.
+// The functions making the calls are kept too, for the call graph.
+func linkClientCalls(indexes []*scip.Index) {
+	calls := 0
+	for id, index := range indexes {
+		// the stub symbols as rewritten in the index.
+		stubs := map[string]string{}
+		clientSymbols.Range(func(key, value any) bool {
+			stubs[rewriteSymbol(key.(string), namespaces[id])] = value.(string)
+			return true
+		})
+		for _, d := range index.Documents {
+			linked := []*scip.Occurrence{}
+			for _, o := range d.Occurrences {
+				methodSymbol, ok := stubs[o.Symbol]
+				if !ok || scip.SymbolRole_Definition.Matches(o) {
+					continue
+				}
+				linked = append(linked, &scip.Occurrence{
+					Range:  o.Range,
+					Symbol: methodSymbol,
+				})
+				whiteListedSymbols.Store(o.Symbol, struct{}{})
+				whiteListedSymbols.Store(methodSymbol, struct{}{})
+				if caller := enclosingFunction(d, o); caller != "" {
+					whiteListedSymbols.Store(caller, struct{}{})
+				}
+			}
+			d.Occurrences = append(d.Occurrences, linked...)
+			calls += len(linked)
+		}
+	}
+	glog.Infof("linked %d client call sites", calls)
+}
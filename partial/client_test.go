@@ -0,0 +1,47 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"testing"
+)
+
+func TestLinkClientCalls(t *testing.T) {
+	const (
+		clientMethod = "scip-go gomod Go_B cb6b82253d24 Go_A/proto/Go_AClient#Go_A_1."
+		protoMethod  = "scip-proto proto protos proto3 protos/Go_A/Go_A_1()."
+	)
+	initIndexes(2, "")
+	clientSymbols.Store(clientMethod, protoMethod)
+	namespaces[1] = "Go_C"
+
+	d := &scip.Document{
+		RelativePath: "main.go",
+		Occurrences: []*scip.Occurrence{
+			{Range: []int32{1, 2, 3}, Symbol: clientMethod, SymbolRoles: int32(scip.SymbolRole_Definition)},
+			{Range: []int32{10, 4, 10}, Symbol: clientMethod},
+			{Range: []int32{11, 4, 10}, Symbol: "scip-go gomod Go_B cb6b82253d24 main/main()."},
+		},
+	}
+	// an index calling the stub with the symbol rewritten for its namespace.
+	other := &scip.Document{
+		RelativePath: "Go_C/main.go",
+		Occurrences:  []*scip.Occurrence{{Range: []int32{5, 4, 10}, Symbol: rewriteSymbol(clientMethod, "Go_C")}},
+	}
+	linkClientCalls([]*scip.Index{{Documents: []*scip.Document{d}}, {Documents: []*scip.Document{other}}})
+
+	if len(d.Occurrences) != 4 {
+		t.Fatalf("expected a single call site to be linked, got %v", d.Occurrences)
+	}
+	o := d.Occurrences[3]
+	if o.Symbol != protoMethod || o.Range[0] != 10 {
+		t.Errorf("expected a reference to %s at line 10, got %v", protoMethod, o)
+	}
+	for _, s := range []string{clientMethod, protoMethod} {
+		if _, ok := whiteListedSymbols.Load(s); !ok {
+			t.Errorf("expected %s to be whitelisted", s)
+		}
+	}
+	if len(other.Occurrences) != 2 || other.Occurrences[1].Symbol != protoMethod {
+		t.Errorf("expected the call of the other index to be linked, got %v", other.Occurrences)
+	}
+}
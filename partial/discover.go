@@ -0,0 +1,150 @@
+package partial
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// scipFileSuffixes are the suffixes of the index files found in the scip
+// directories, compressed or not.
+var scipFileSuffixes = []string{".scip", ".scip.gz", ".scip.zst", ".scip.zstd"}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// FindScipFiles returns the index files found in the directories and their
+// subdirectories. The include and exclude globs are matched against the path
+// of the files relative to their directory, or against their name for the
+// globs without a slash, and ** matches any number of directories. The files
+// matching one of the include globs, if any, and none of the exclude globs
+// are returned.
+func FindScipFiles(dirs []string, include []string, exclude []string) ([]string, error) {
+	files := []string{}
+	seen := map[string]struct{}{}
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !isScipFile(p) {
+				return err
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if len(include) > 0 && !matchOneOf(include, rel) || matchOneOf(exclude, rel) {
+				return nil
+			}
+			if _, ok := seen[p]; !ok {
+				seen[p] = struct{}{}
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func isScipFile(p string) bool {
+	for _, suffix := range scipFileSuffixes {
+		if strings.HasSuffix(p, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchOneOf(globs []string, rel string) bool {
+	for _, glob := range globs {
+		if !strings.Contains(glob, "/") {
+			if ok, _ := path.Match(glob, path.Base(rel)); ok {
+				return true
+			}
+		} else if matchGlob(strings.Split(glob, "/"), strings.Split(rel, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches the segments of a path against the ones of a glob.
+func matchGlob(glob []string, segments []string) bool {
+	if len(glob) == 0 {
+		return len(segments) == 0
+	}
+	if glob[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(glob[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(glob[0], segments[0]); !ok {
+		return false
+	}
+	return matchGlob(glob[1:], segments[1:])
+}
+
+// openScipFile opens an index file, and decompresses it if it starts with
+// the magic number of gzip or zstd.
+func openScipFile(p string) (io.ReadCloser, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(f)
+	magic, _ := r.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &scipFileReader{Reader: gz, closers: []io.Closer{gz, f}}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &scipFileReader{Reader: zr, closers: []io.Closer{zr.IOReadCloser(), f}}, nil
+	}
+	return &scipFileReader{Reader: r, closers: []io.Closer{f}}, nil
+}
+
+// scipFileReader reads an index file through its decompressor, and closes
+// both of them.
+type scipFileReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *scipFileReader) Close() error {
+	var err error
+	for _, c := range r.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
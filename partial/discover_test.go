@@ -0,0 +1,82 @@
+package partial
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestFindScipFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"Go_A.scip", "py/pyA.scip.gz", "ts/web/tsA.scip.zst", "ts/web/tsA.json", "vendor/dep.scip"} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{want: []string{"Go_A.scip", "py/pyA.scip.gz", "ts/web/tsA.scip.zst", "vendor/dep.scip"}},
+		{exclude: []string{"vendor/**"}, want: []string{"Go_A.scip", "py/pyA.scip.gz", "ts/web/tsA.scip.zst"}},
+		{include: []string{"ts/**"}, want: []string{"ts/web/tsA.scip.zst"}},
+		{include: []string{"*.scip"}, exclude: []string{"dep.*"}, want: []string{"Go_A.scip"}},
+	}
+	for _, test := range tests {
+		files, err := FindScipFiles([]string{dir, dir}, test.include, test.exclude)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := []string{}
+		for _, f := range files {
+			rel, _ := filepath.Rel(dir, f)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("include %v exclude %v: got %v, want %v", test.include, test.exclude, got, test.want)
+		}
+	}
+}
+
+func TestOpenScipFile(t *testing.T) {
+	content := []byte("\x0a\x00plain index")
+	var gz, zst bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(content)
+	gw.Close()
+	zw, err := zstd.NewWriter(&zst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Write(content)
+	zw.Close()
+
+	dir := t.TempDir()
+	for name, b := range map[string][]byte{"a.scip": content, "a.scip.gz": gz.Bytes(), "a.scip.zst": zst.Bytes()} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		r, err := openScipFile(p)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil || !bytes.Equal(got, content) {
+			t.Errorf("%s: got %q, %v", name, got, err)
+		}
+	}
+}
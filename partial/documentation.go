@@ -0,0 +1,146 @@
+package partial
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// documentation returns the Documentation of a proto symbol, i.e. its
+// signature followed by its leading and trailing comments.
+func documentation(signature string, comments protogen.CommentSet) []string {
+	doc := []string{"```proto\n" + signature + "\n```"}
+	for _, c := range []protogen.Comments{comments.Leading, comments.Trailing} {
+		if text := formatComments(c); text != "" {
+			doc = append(doc, text)
+		}
+	}
+	return doc
+}
+
+// formatComments removes the space following the comment markers and the
+// trailing new line of c.
+func formatComments(c protogen.Comments) string {
+	lines := strings.Split(strings.TrimRight(string(c), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func serviceSignature(s *protogen.Service) string {
+	return "service " + string(s.Desc.Name()) + formatOptions(s.Desc.Options())
+}
+
+// methodSignature renders a method as declared, e.g.
+// rpc Go_A_1(stream CommonMessage) returns (CommonMessage).
+func methodSignature(m *protogen.Method) string {
+	streaming := func(stream bool) string {
+		if stream {
+			return "stream "
+		}
+		return ""
+	}
+	return fmt.Sprintf("rpc %s(%s%s) returns (%s%s)%s",
+		m.Desc.Name(),
+		streaming(m.Desc.IsStreamingClient()), typeName(m.Desc.ParentFile(), m.Desc.Input()),
+		streaming(m.Desc.IsStreamingServer()), typeName(m.Desc.ParentFile(), m.Desc.Output()),
+		formatOptions(m.Desc.Options()))
+}
+
+func messageSignature(m *protogen.Message) string {
+	return "message " + string(m.Desc.Name()) + formatOptions(m.Desc.Options())
+}
+
+// fieldSignature renders a field as declared, e.g. repeated int32 my_numbers = 6.
+func fieldSignature(field *protogen.Field) string {
+	desc := field.Desc
+	var kind string
+	switch {
+	case desc.IsMap():
+		kind = fmt.Sprintf("map<%s, %s>", fieldTypeName(desc.MapKey()), fieldTypeName(desc.MapValue()))
+	case desc.IsList():
+		kind = "repeated " + fieldTypeName(desc)
+	case desc.HasOptionalKeyword():
+		kind = "optional " + fieldTypeName(desc)
+	case desc.Cardinality() == protoreflect.Required:
+		kind = "required " + fieldTypeName(desc)
+	default:
+		kind = fieldTypeName(desc)
+	}
+	return fmt.Sprintf("%s %s = %d%s", kind, desc.Name(), desc.Number(), formatOptions(desc.Options()))
+}
+
+func oneofSignature(oneof *protogen.Oneof) string {
+	return "oneof " + string(oneof.Desc.Name())
+}
+
+func enumSignature(e *protogen.Enum) string {
+	return "enum " + string(e.Desc.Name()) + formatOptions(e.Desc.Options())
+}
+
+func enumValueSignature(v *protogen.EnumValue) string {
+	return fmt.Sprintf("%s = %d%s", v.Desc.Name(), v.Desc.Number(), formatOptions(v.Desc.Options()))
+}
+
+func fieldTypeName(desc protoreflect.FieldDescriptor) string {
+	switch {
+	case desc.Message() != nil:
+		return typeName(desc.ParentFile(), desc.Message())
+	case desc.Enum() != nil:
+		return typeName(desc.ParentFile(), desc.Enum())
+	}
+	return desc.Kind().String()
+}
+
+// typeName returns the name of a message or enum as written in f, i.e. without
+// the package of f.
+func typeName(f protoreflect.FileDescriptor, desc protoreflect.Descriptor) string {
+	name := string(desc.FullName())
+	if pkg := string(f.Package()); pkg != "" && strings.HasPrefix(name, pkg+".") {
+		return strings.TrimPrefix(name, pkg+".")
+	}
+	return name
+}
+
+// formatOptions renders the options set on a descriptor, e.g.
+// [deprecated = true].
+func formatOptions(opts protoreflect.ProtoMessage) string {
+	if opts == nil {
+		return ""
+	}
+	options := []string{}
+	opts.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := string(fd.Name())
+		if fd.IsExtension() {
+			name = "(" + string(fd.FullName()) + ")"
+		}
+		options = append(options, name+" = "+formatOptionValue(fd, v))
+		return true
+	})
+	if len(options) == 0 {
+		return ""
+	}
+	sort.Strings(options)
+	return " [" + strings.Join(options, ", ") + "]"
+}
+
+func formatOptionValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch {
+	case fd.IsList() || fd.IsMap():
+		return "..."
+	case fd.Message() != nil:
+		return "{ " + prototext.MarshalOptions{}.Format(v.Message().Interface()) + " }"
+	case fd.Enum() != nil:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+	case fd.Kind() == protoreflect.StringKind:
+		return fmt.Sprintf("%q", v.String())
+	}
+	return v.String()
+}
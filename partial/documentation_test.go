@@ -0,0 +1,39 @@
+package partial
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestDocumentation(t *testing.T) {
+	fd := newTestFileDescriptor("Go_A", "Go_A_1")
+	method := fd.Service[0].Method[0]
+	method.ClientStreaming = proto.Bool(true)
+	method.Options = &descriptorpb.MethodOptions{Deprecated: proto.Bool(true)}
+	fd.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{
+		{Path: []int32{6, 0, 2, 0}, Span: []int32{5, 2, 40}, LeadingComments: proto.String(" Go_A_1 does things.\n Twice.\n"), TrailingComments: proto.String(" trailing\n")},
+	}}
+	f := newTestFileFromDescriptor(t, fd)
+	m := f.Services[0].Methods[0]
+
+	got := documentation(methodSignature(m), m.Comments)
+	want := []string{
+		"```proto\nrpc Go_A_1(stream Empty) returns (Empty) [deprecated = true]\n```",
+		"Go_A_1 does things.\nTwice.",
+		"trailing",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("documentation = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("documentation[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if got := documentation(serviceSignature(f.Services[0]), f.Services[0].Comments); len(got) != 1 || got[0] != "```proto\nservice Go_A\n```" {
+		t.Errorf("unexpected service documentation %q", got)
+	}
+}
@@ -0,0 +1,232 @@
+package partial
+
+import (
+	"fmt"
+	"protoc-gen-scip/scip"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// httpRuleField is the number of the google.api.http extension of
+// google.protobuf.MethodOptions. The extension is read from the wire format,
+// so that the plugin does not depend on the googleapis packages.
+const httpRuleField = 72295728
+
+const (
+	methodOptionsField         = 4
+	httpRuleBodyField          = 7
+	httpRuleCustomField        = 8
+	httpRuleBindingsField      = 11
+	httpRuleResponseBodyField  = 12
+	customHttpPatternKindField = 1
+	customHttpPatternPathField = 2
+)
+
+// httpRuleMethods maps the pattern fields of google.api.HttpRule to their
+// HTTP method.
+var httpRuleMethods = map[protowire.Number]string{
+	2: "GET",
+	3: "PUT",
+	4: "POST",
+	5: "DELETE",
+	6: "PATCH",
+}
+
+// RouteMatch holds the symbols matched for one HTTP binding of a method, e.g.
+// the handlers generated by grpc-gateway for it.
+type RouteMatch struct {
+	Method *protogen.Method
+	// Index is the position of the binding, 0 for the rule itself and the
+	// following ones for its additional bindings.
+	Index   int
+	Symbols []*scip.SymbolInformation
+}
+
+// httpRule is a binding of a google.api.http option.
+type httpRule struct {
+	Method       string
+	Path         string
+	Body         string
+	ResponseBody string
+	// path is the source path of the binding in the proto file.
+	path protoreflect.SourcePath
+}
+
+func (r *httpRule) String() string {
+	return r.Method + " " + r.Path
+}
+
+// httpRules returns the bindings of the google.api.http option of m, the rule
+// itself first and then its additional bindings, as numbered by grpc-gateway.
+func httpRules(m *protogen.Method) []*httpRule {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(m.Desc.Options())
+	if err != nil {
+		return nil
+	}
+	optionsPath := append(append(protoreflect.SourcePath{}, m.Location.Path...), methodOptionsField, httpRuleField)
+	rules := []*httpRule{}
+	forEachField(b, func(num protowire.Number, v []byte) {
+		if num == httpRuleField {
+			rules = append(rules, parseHttpRule(v, optionsPath)...)
+		}
+	})
+	return rules
+}
+
+// parseHttpRule returns the binding encoded in b followed by its additional
+// bindings.
+func parseHttpRule(b []byte, path protoreflect.SourcePath) []*httpRule {
+	rule := &httpRule{path: path}
+	bindings := []*httpRule{}
+	forEachField(b, func(num protowire.Number, v []byte) {
+		switch num {
+		case httpRuleBodyField:
+			rule.Body = string(v)
+		case httpRuleResponseBodyField:
+			rule.ResponseBody = string(v)
+		case httpRuleCustomField:
+			forEachField(v, func(num protowire.Number, v []byte) {
+				switch num {
+				case customHttpPatternKindField:
+					rule.Method = string(v)
+				case customHttpPatternPathField:
+					rule.Path = string(v)
+				}
+			})
+		case httpRuleBindingsField:
+			bindingPath := append(append(protoreflect.SourcePath{}, path...), httpRuleBindingsField, int32(len(bindings)))
+			bindings = append(bindings, parseHttpRule(v, bindingPath)[0])
+		default:
+			if method, ok := httpRuleMethods[num]; ok {
+				rule.Method, rule.Path = method, string(v)
+			}
+		}
+	})
+	return append([]*httpRule{rule}, bindings...)
+}
+
+// forEachField calls fn with the length delimited fields of the message
+// encoded in b, and skips the other ones.
+func forEachField(b []byte, fn func(protowire.Number, []byte)) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return
+		}
+		b = b[n:]
+		if typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return
+			}
+			fn(num, v)
+			b = b[n:]
+			continue
+		}
+		if n = protowire.ConsumeFieldValue(num, typ, b); n < 0 {
+			return
+		}
+		b = b[n:]
+	}
+}
+
+func getRouteKey(m *protogen.Method, index int) string {
+	return fmt.Sprintf("%s#route%d", getMethodKey(m), index)
+}
+
+// makeRouteSymbol returns the symbol of an HTTP binding of a method, e.g.
+// Go_A#Go_A_1.`GET /v1/go_a/{id}`:.
+func makeRouteSymbol(f *protogen.File, m *protogen.Method, rule *httpRule) string {
+	return makeMethodSymbol(f, m) + "`" + strings.ReplaceAll(rule.String(), "`", "``") + "`:"
+}
+
+// generateRoutes adds the symbols of the HTTP bindings of a method, defined
+// at their google.api.http option.
+func generateRoutes(f *protogen.File, m *protogen.Method, d *scip.Document, siMap map[string]*scip.SymbolInformation) {
+	for i, rule := range httpRules(m) {
+		if rule.Method == "" {
+			continue
+		}
+		symbol := makeRouteSymbol(f, m, rule)
+		symbolInfo := makeSymbolInformation(symbol, scip.SymbolInformation_Method)
+		symbolInfo.Documentation = []string{"```http\n" + rule.String() + "\n```", routeDocumentation(m, rule)}
+
+		occurence := makeDefinition(f, m.Location.Path, symbol)
+		if pos := f.Desc.SourceLocations().ByPath(rule.path); len(pos.Path) != 0 {
+			occurence = makeOccurence(pos, symbol)
+			occurence.SymbolRoles = int32(scip.SymbolRole_Definition)
+		}
+
+		siMap[getRouteKey(m, i)] = symbolInfo
+		d.Symbols = append(d.Symbols, symbolInfo)
+		d.Occurrences = append(d.Occurrences, occurence)
+	}
+}
+
+// routeDocumentation tells which method a binding maps to and which parts of
+// the messages make up the HTTP bodies.
+func routeDocumentation(m *protogen.Method, rule *httpRule) string {
+	doc := fmt.Sprintf("HTTP binding of `%s`.", m.Desc.FullName())
+	if rule.Body != "" {
+		doc += fmt.Sprintf(" Request body: `%s`.", rule.Body)
+	}
+	if rule.ResponseBody != "" {
+		doc += fmt.Sprintf(" Response body: `%s`.", rule.ResponseBody)
+	}
+	return doc
+}
+
+// httpRulesDocumentation lists the HTTP bindings of a method for its
+// documentation.
+func httpRulesDocumentation(m *protogen.Method) []string {
+	lines := []string{}
+	for _, rule := range httpRules(m) {
+		if rule.Method != "" {
+			lines = append(lines, "- `"+rule.String()+"`")
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []string{"HTTP bindings:\n" + strings.Join(lines, "\n")}
+}
+
+// matchGoGateway matches the handlers generated by grpc-gateway in the package
+// of t, i.e. the request_<Service>_<Method>_<i>, local_request_*, pattern_*
+// and filter_* functions and variables of each HTTP binding.
+func matchGoGateway(s *protogen.Service, t *ScipType) *MatchResult {
+	res := &MatchResult{Methods: map[*protogen.Method][]*scip.SymbolInformation{}, Score: 2}
+	for _, m := range s.Methods {
+		for i, rule := range httpRules(m) {
+			if rule.Method == "" {
+				continue
+			}
+			route := &RouteMatch{Method: m, Index: i}
+			suffix := fmt.Sprintf("%s_%s_%d", s.Desc.Name(), m.Desc.Name(), i)
+			for _, prefix := range []string{"request_", "local_request_", "pattern_", "filter_"} {
+				route.Symbols = append(route.Symbols, t.findMembers(prefix+suffix)...)
+			}
+			if len(route.Symbols) > 0 {
+				res.Routes = append(res.Routes, route)
+			}
+		}
+	}
+	if len(res.Routes) == 0 {
+		return nil
+	}
+	return res
+}
+
+// addRouteSymbols maps the symbols of the matched routes to the keys of their
+// proto symbols.
+func addRouteSymbols(routes []*RouteMatch, siMap map[*scip.SymbolInformation]string) {
+	for _, route := range routes {
+		for _, si := range route.Symbols {
+			siMap[si] = getRouteKey(route.Method, route.Index)
+		}
+	}
+}
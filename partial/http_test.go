@@ -0,0 +1,76 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// newHttpRule encodes a google.api.HttpRule with the given pattern field and
+// additional bindings.
+func newHttpRule(field protowire.Number, path string, body string, bindings ...[]byte) []byte {
+	b := protowire.AppendTag(nil, field, protowire.BytesType)
+	b = protowire.AppendString(b, path)
+	if body != "" {
+		b = protowire.AppendTag(b, httpRuleBodyField, protowire.BytesType)
+		b = protowire.AppendString(b, body)
+	}
+	for _, binding := range bindings {
+		b = protowire.AppendTag(b, httpRuleBindingsField, protowire.BytesType)
+		b = protowire.AppendBytes(b, binding)
+	}
+	return b
+}
+
+func TestHttpRules(t *testing.T) {
+	fd := newTestFileDescriptor("Go_A", "Go_A_1", "Go_A_2")
+	rule := newHttpRule(2, "/v1/go_a/{id}", "", newHttpRule(4, "/v1/go_a", "*"))
+	opts := &descriptorpb.MethodOptions{}
+	raw := protowire.AppendTag(nil, httpRuleField, protowire.BytesType)
+	opts.ProtoReflect().SetUnknown(protowire.AppendBytes(raw, rule))
+	fd.Service[0].Method[0].Options = opts
+	f := newTestFileFromDescriptor(t, fd)
+	s := f.Services[0]
+
+	rules := httpRules(s.Methods[0])
+	if len(rules) != 2 || rules[0].String() != "GET /v1/go_a/{id}" || rules[1].String() != "POST /v1/go_a" || rules[1].Body != "*" {
+		t.Fatalf("unexpected rules %v", rules)
+	}
+	if len(httpRules(s.Methods[1])) != 0 {
+		t.Errorf("expected no rule for Go_A_2")
+	}
+
+	d := &scip.Document{}
+	symbols := generateService(f, s, d)
+	route := symbols[getRouteKey(s.Methods[0], 0)]
+	if route == nil || route.Symbol != "scip-proto proto protos proto3 proto/Go_A/Go_A#Go_A_1.`GET /v1/go_a/{id}`:" {
+		t.Fatalf("unexpected route symbol %v", route)
+	}
+	if _, err := scip.ParseSymbol(route.Symbol); err != nil {
+		t.Errorf("expected a valid symbol: %v", err)
+	}
+	if symbols[getRouteKey(s.Methods[0], 1)] == nil {
+		t.Errorf("expected a symbol for the additional binding")
+	}
+	if doc := symbols["Go_AGo_A_1"].Documentation; !strings.Contains(doc[len(doc)-1], "`POST /v1/go_a`") {
+		t.Errorf("expected the bindings in the method documentation, got %v", doc)
+	}
+
+	gateway := newTestType(t,
+		goPackage,
+		goPackage+"request_Go_A_Go_A_1_0().",
+		goPackage+"pattern_Go_A_Go_A_1_0.",
+		goPackage+"request_Go_A_Go_A_1_1().",
+		goPackage+"RegisterGo_AHandler().",
+	)
+	res := goGrpcMatcher{}.Match(s, gateway)
+	if res == nil || len(res.Routes) != 2 {
+		t.Fatalf("expected both bindings of Go_A_1 to match, got %v", res)
+	}
+	if r := res.Routes[0]; r.Method != s.Methods[0] || r.Index != 0 || len(r.Symbols) != 2 {
+		t.Errorf("unexpected match for the first binding: %v", r)
+	}
+}
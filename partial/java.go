@@ -0,0 +1,200 @@
+package partial
+
+import (
+	"path"
+	"protoc-gen-scip/scip"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func init() {
+	RegisterMatcher("java-grpc", javaGrpcMatcher{})
+}
+
+// javaGrpcMatcher matches the code generated by grpc-java and indexed by
+// scip-java, that is
+//   - the <Service>ImplBase, <Service>Stub, <Service>BlockingStub,
+//     <Service>FutureStub and AsyncService classes nested in <Service>Grpc,
+//   - the classes implementing the service by extending <Service>ImplBase.
+//
+// grpc-java always generates <Service>Grpc in a file of its own, so only the
+// java_package option is needed to locate it, java_outer_classname and
+// java_multiple_files only apply to the message classes.
+type javaGrpcMatcher struct{}
+
+func (javaGrpcMatcher) Match(s *protogen.Service, t *ScipType) *MatchResult {
+	if t.Module || !strings.HasPrefix(t.TypeSymbol.Symbol, "semanticdb ") {
+		return nil
+	}
+	name := string(s.Desc.Name())
+	pkg := javaPackagePath(s.Desc.ParentFile().Options().(*descriptorpb.FileOptions), string(s.Desc.ParentFile().Package()))
+	grpcClass := "::" + name + "Grpc::"
+
+	generated, client := false, false
+	if hasPathSuffix(t.Scope(), pkg) {
+		for _, nested := range []string{name + "ImplBase", "AsyncService"} {
+			generated = generated || t.TypeName() == grpcClass+nested
+		}
+		for _, nested := range []string{name + "Stub", name + "BlockingStub", name + "FutureStub"} {
+			client = client || t.TypeName() == grpcClass+nested
+		}
+	}
+	if !generated && !client && !implementsType(t.TypeSymbol, func(symbol string, _ []*scip.Descriptor) bool {
+		return strings.HasSuffix(symbol, pkg+"/"+name+"Grpc#"+name+"ImplBase#")
+	}) {
+		return nil
+	}
+
+	res := &MatchResult{Methods: map[*protogen.Method][]*scip.SymbolInformation{}, Score: 2, Client: client}
+	for _, m := range s.Methods {
+		if matches := t.findMembers(javaMethodName(string(m.Desc.Name()))); len(matches) > 0 {
+			res.Methods[m] = matches
+		}
+	}
+	return res
+}
+
+// InScope matches the java_package of f.
+func (javaGrpcMatcher) InScope(f *protogen.File, scope string) bool {
+	return hasPathSuffix(scope, javaPackagePath(f.Desc.Options().(*descriptorpb.FileOptions), string(f.Desc.Package())))
+}
+
+// MatchMessage matches the class generated by protoc for m, see
+// javaMessageClass, and the getters of its fields.
+func (javaGrpcMatcher) MatchMessage(m *protogen.Message, t *ScipType) *MessageMatchResult {
+	if !strings.HasPrefix(t.TypeSymbol.Symbol, "semanticdb ") || t.TypeName() != "::"+javaMessageClass(m) {
+		return nil
+	}
+	file := m.Desc.ParentFile()
+	if !hasPathSuffix(t.Scope(), javaPackagePath(file.Options().(*descriptorpb.FileOptions), string(file.Package()))) {
+		return nil
+	}
+
+	res := &MessageMatchResult{Fields: map[*protogen.Field][]*scip.SymbolInformation{}, Score: 2}
+	for _, field := range m.Fields {
+		getter := "get" + javaCamelCase(string(field.Desc.Name()))
+		if field.Desc.IsMap() {
+			getter += "Map"
+		} else if field.Desc.IsList() {
+			getter += "List"
+		}
+		if matches := t.findMembers(getter); len(matches) > 0 {
+			res.Fields[field] = matches
+		}
+	}
+	return res
+}
+
+// javaPackagePath returns the namespaces of the generated classes as written
+// in the scip-java symbols, e.g. com/example/foo for com.example.foo.
+func javaPackagePath(opts *descriptorpb.FileOptions, protoPackage string) string {
+	pkg := protoPackage
+	if opts.GetJavaPackage() != "" {
+		pkg = opts.GetJavaPackage()
+	}
+	return strings.ReplaceAll(pkg, ".", "/")
+}
+
+// javaMessageClass returns the class generated by protoc for m, as nested
+// types are written in the scip-java symbols, e.g. Outer::Message::Nested.
+// The class is nested in the outer class of the file unless
+// java_multiple_files is set.
+func javaMessageClass(m *protogen.Message) string {
+	name := relativeMessageName(m, "::")
+	file := m.Desc.ParentFile()
+	if file.Options().(*descriptorpb.FileOptions).GetJavaMultipleFiles() {
+		return name
+	}
+	return javaOuterClassName(file) + "::" + name
+}
+
+// javaOuterClassName returns the outer class generated by protoc for the
+// file: java_outer_classname, or else the camel cased name of the file,
+// suffixed with OuterClass when one of the types of the file has this name.
+func javaOuterClassName(file protoreflect.FileDescriptor) string {
+	if name := file.Options().(*descriptorpb.FileOptions).GetJavaOuterClassname(); name != "" {
+		return name
+	}
+	base := strings.TrimSuffix(path.Base(file.Path()), ".proto")
+	name := javaCamelCase(base)
+	if javaHasType(file.Messages(), file.Enums(), name) {
+		return name + "OuterClass"
+	}
+	for i := 0; i < file.Services().Len(); i++ {
+		if string(file.Services().Get(i).Name()) == name {
+			return name + "OuterClass"
+		}
+	}
+	return name
+}
+
+// javaHasType reports whether one of the messages or enums, or of the types
+// nested in the messages, is named name.
+func javaHasType(messages protoreflect.MessageDescriptors, enums protoreflect.EnumDescriptors, name string) bool {
+	for i := 0; i < enums.Len(); i++ {
+		if string(enums.Get(i).Name()) == name {
+			return true
+		}
+	}
+	for i := 0; i < messages.Len(); i++ {
+		m := messages.Get(i)
+		if string(m.Name()) == name || javaHasType(m.Messages(), m.Enums(), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// javaCamelCase converts a field or file name as protoc does for the Java
+// names, i.e. the first letter is upper cased and the underscores and other
+// symbols are removed, upper casing the letter following them or a digit:
+// my_int32_field becomes MyInt32Field and field1name Field1Name.
+func javaCamelCase(name string) string {
+	var b strings.Builder
+	upperNext := true
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z':
+			if upperNext {
+				r = unicode.ToUpper(r)
+			}
+			b.WriteRune(r)
+			upperNext = false
+		case r >= 'A' && r <= 'Z':
+			b.WriteRune(r)
+			upperNext = false
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+			upperNext = true
+		default:
+			upperNext = true
+		}
+	}
+	return b.String()
+}
+
+// javaMethodName converts a method name as grpc-java does, i.e. the first
+// letter is lower cased and the underscores are removed, upper casing the
+// letter following them: Go_A_1 becomes goA1.
+func javaMethodName(method string) string {
+	var b strings.Builder
+	afterUnderscore := false
+	for i, r := range method {
+		switch {
+		case i == 0:
+			b.WriteRune(unicode.ToLower(r))
+		case r == '_':
+			afterUnderscore = true
+		case afterUnderscore:
+			b.WriteRune(unicode.ToUpper(r))
+			afterUnderscore = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const javaPackage = "semanticdb maven . . "
+
+func TestJavaMethodName(t *testing.T) {
+	tests := map[string]string{
+		"Go_A_1":     "goA1",
+		"SayHello":   "sayHello",
+		"say_hello":  "sayHello",
+		"GetV1_Info": "getV1Info",
+	}
+	for method, want := range tests {
+		if got := javaMethodName(method); got != want {
+			t.Errorf("javaMethodName(%q) = %q, want %q", method, got, want)
+		}
+	}
+}
+
+func TestJavaCamelCase(t *testing.T) {
+	tests := map[string]string{
+		"my_int32_field": "MyInt32Field",
+		"field1name":     "Field1Name",
+		"Go_A":           "GoA",
+		"my-protos.v2":   "MyProtosV2",
+	}
+	for name, want := range tests {
+		if got := javaCamelCase(name); got != want {
+			t.Errorf("javaCamelCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestJavaMessageClass(t *testing.T) {
+	newFile := func(opts *descriptorpb.FileOptions, messages ...string) *descriptorpb.FileDescriptorProto {
+		fd := newTestFileDescriptor("Go_A")
+		if opts != nil {
+			opts.GoPackage = fd.Options.GoPackage
+			fd.Options = opts
+		}
+		fd.MessageType = []*descriptorpb.DescriptorProto{{
+			Name:       proto.String(messages[0]),
+			NestedType: []*descriptorpb.DescriptorProto{{Name: proto.String("Inner")}},
+		}}
+		for _, m := range messages[1:] {
+			fd.MessageType = append(fd.MessageType, &descriptorpb.DescriptorProto{Name: proto.String(m)})
+		}
+		return fd
+	}
+	tests := []struct {
+		fd   *descriptorpb.FileDescriptorProto
+		want []string
+	}{
+		{newFile(nil, "CommonMessage"), []string{"GoA::CommonMessage", "GoA::CommonMessage::Inner"}},
+		{newFile(&descriptorpb.FileOptions{JavaOuterClassname: proto.String("Messages")}, "CommonMessage"), []string{"Messages::CommonMessage", "Messages::CommonMessage::Inner"}},
+		{newFile(&descriptorpb.FileOptions{JavaMultipleFiles: proto.Bool(true)}, "CommonMessage"), []string{"CommonMessage", "CommonMessage::Inner"}},
+		{newFile(nil, "CommonMessage", "GoA"), []string{"GoAOuterClass::CommonMessage", "GoAOuterClass::CommonMessage::Inner", "GoAOuterClass::GoA"}},
+	}
+	for _, test := range tests {
+		f := newTestFileFromDescriptor(t, test.fd)
+		got := []string{}
+		for _, m := range f.Messages {
+			got = append(got, javaMessageClass(m))
+			for _, nested := range m.Messages {
+				got = append(got, javaMessageClass(nested))
+			}
+		}
+		if strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("options %v: got %v, want %v", test.fd.Options, got, test.want)
+		}
+	}
+}
+
+func TestJavaGrpcMatcher(t *testing.T) {
+	// newTestFile does not set java_package, the proto package is used instead.
+	f := newTestFile(t, "Go_A", "Go_A_1", "Go_A_2")
+	s := f.Services[0]
+
+	implBase := newTestType(t,
+		javaPackage+"protos/Go_AGrpc#Go_AImplBase#",
+		javaPackage+"protos/Go_AGrpc#Go_AImplBase#goA1().",
+		javaPackage+"protos/Go_AGrpc#Go_AImplBase#goA1().(request)",
+		javaPackage+"protos/Go_AGrpc#Go_AImplBase#goA2().",
+		javaPackage+"protos/Go_AGrpc#Go_AImplBase#bindService().",
+	)
+	res := javaGrpcMatcher{}.Match(s, implBase)
+	if res == nil {
+		t.Fatalf("expected Go_AImplBase to match")
+	}
+	for _, m := range s.Methods {
+		if len(res.Methods[m]) != 1 {
+			t.Errorf("expected a single symbol for %s, got %v", m.GoName, res.Methods[m])
+		}
+	}
+
+	prefixed := newTestType(t, javaPackage+"Java_A/protos/Go_AGrpc#Go_ABlockingStub#")
+	if res := (javaGrpcMatcher{}).Match(s, prefixed); res == nil || !res.Client {
+		t.Errorf("expected a stub under a prefixed scope to match as a client, got %v", res)
+	}
+
+	otherPackage := newTestType(t, javaPackage+"other/Go_AGrpc#Go_ABlockingStub#")
+	if res := (javaGrpcMatcher{}).Match(s, otherPackage); res != nil {
+		t.Errorf("expected a stub of another package not to match, got %v", res)
+	}
+
+	initIndexes(1, "")
+	addScipTypeFromSymbolInformation(0, &scip.SymbolInformation{
+		Symbol: javaPackage + "com/example/Server#",
+		Relationships: []*scip.Relationship{{
+			Symbol:           javaPackage + "protos/Go_AGrpc#Go_AImplBase#",
+			IsImplementation: true,
+		}},
+	}, "")
+	addScipTypeFromSymbolInformation(0, &scip.SymbolInformation{Symbol: javaPackage + "com/example/Server#goA2()."}, "")
+	if res := (javaGrpcMatcher{}).Match(s, typeMaps[0]["com/example/::Server"]); res == nil || len(res.Methods[s.Methods[1]]) != 1 {
+		t.Errorf("expected the subclass of Go_AImplBase to match, got %v", res)
+	}
+}
@@ -0,0 +1,269 @@
+package partial
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/golang/glog"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// Params are the parameters of the plugin, as given to protoc or to the link
+// command of the tool.
+type Params struct {
+	// ScipDirs are the directories holding the SCIP indexes to link, the
+	// working directory if it is empty.
+	ScipDirs []string
+	// Include and Exclude are globs selecting the indexes, see
+	// FindScipFiles.
+	Include []string
+	Exclude []string
+	// OutFile is the name of the linked index.
+	OutFile string
+	// SourceRoot is the absolute source root of the linked index.
+	SourceRoot string
+	// ProtoRoot is the directory the names of the proto files are relative
+	// to, the working directory if it is empty.
+	ProtoRoot string
+	// Matchers are the names of the matchers to use, all of them if empty.
+	Matchers []string
+	Fuzzy    bool
+	Unscoped bool
+	// MappingFile is the YAML file declaring links in addition to the
+	// matched ones.
+	MappingFile string
+	// ManifestFile is the YAML file describing the indexes, see Manifest.
+	ManifestFile string
+	// Mode is the name of the Mode of the merge.
+	Mode string
+	// Rewrite is the name of the Rewrite of the symbols.
+	Rewrite string
+	// Encoding is the name of the text encoding of the linked index, see
+	// ParseEncoding.
+	Encoding string
+}
+
+// Generate links the files to generate of gen with the SCIP indexes found in
+// params.ScipDirs.
+func Generate(gen *protogen.Plugin, params Params) error {
+	gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+	inputFiles := []*protogen.File{}
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		inputFiles = append(inputFiles, f)
+	}
+
+	if len(params.ScipDirs) == 0 {
+		params.ScipDirs = []string{"."}
+	}
+	scipFiles, err := FindScipFiles(params.ScipDirs, params.Include, params.Exclude)
+	if err != nil {
+		return fmt.Errorf("failed to scan the directory for scip index: %w", err)
+	}
+	var manifest *Manifest
+	if params.ManifestFile != "" {
+		if manifest, err = LoadManifest(params.ManifestFile); err != nil {
+			return err
+		}
+	}
+	if len(manifest.addIndexes(scipFiles)) == 0 {
+		glog.Errorf("no index to be analyzed")
+		return nil
+	}
+
+	if !filepath.IsAbs(params.SourceRoot) {
+		glog.Error("the source root is not an absolute path")
+		params.SourceRoot = ""
+	}
+	matchers, err := LookupMatchers(params.Matchers)
+	if err != nil {
+		return err
+	}
+	mode, err := ParseMode(params.Mode)
+	if err != nil {
+		return err
+	}
+	rewrite, err := ParseRewrite(params.Rewrite)
+	if err != nil {
+		return err
+	}
+	encoding, err := ParseEncoding(params.Encoding)
+	if err != nil {
+		return err
+	}
+	var mapping *Mapping
+	if params.MappingFile != "" {
+		if mapping, err = LoadMapping(params.MappingFile); err != nil {
+			return err
+		}
+	}
+	GenerateFile(gen, inputFiles, Options{
+		ScipFiles:  scipFiles,
+		OutputPath: params.OutFile,
+		SourceRoot: params.SourceRoot,
+		ProtoRoot:  params.ProtoRoot,
+		Matchers:   matchers,
+		Fuzzy:      params.Fuzzy,
+		Unscoped:   params.Unscoped,
+		Mapping:    mapping,
+		Manifest:   manifest,
+		Mode:       mode,
+		Rewrite:    rewrite,
+		Encoding:   encoding,
+	})
+	return nil
+}
+
+// Link runs the plugin on req, as protogen.Options.Run does for the request
+// read from protoc, and returns its response.
+func Link(req *pluginpb.CodeGeneratorRequest, params Params) (*pluginpb.CodeGeneratorResponse, error) {
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := Generate(gen, params); err != nil {
+		gen.Error(err)
+	}
+	return gen.Response(), nil
+}
+
+// CompileRequest compiles the proto files, given relative to the import
+// paths, and returns the request protoc would send to the plugin for them.
+func CompileRequest(ctx context.Context, importPaths []string, files []string) (*pluginpb.CodeGeneratorRequest, error) {
+	compiler := protocompile.Compiler{
+		Resolver:       protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: importPaths}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	compiled, err := compiler.Compile(ctx, files...)
+	if err != nil {
+		return nil, err
+	}
+
+	// like protoc, list the files in topological order and fill in the JSON
+	// names of the fields.
+	req := &pluginpb.CodeGeneratorRequest{FileToGenerate: files}
+	seen := map[string]struct{}{}
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if _, ok := seen[fd.Path()]; ok {
+			return
+		}
+		seen[fd.Path()] = struct{}{}
+		for i := 0; i < fd.Imports().Len(); i++ {
+			add(fd.Imports().Get(i).FileDescriptor)
+		}
+		fdp := protodesc.ToFileDescriptorProto(fd)
+		setJSONNames(fd.Messages(), fdp.MessageType)
+		setExtensionJSONNames(fd.Extensions(), fdp.Extension)
+		req.ProtoFile = append(req.ProtoFile, fdp)
+	}
+	for _, fd := range compiled {
+		add(fd)
+	}
+	return req, nil
+}
+
+func setJSONNames(messages protoreflect.MessageDescriptors, protos []*descriptorpb.DescriptorProto) {
+	for i, mp := range protos {
+		m := messages.Get(i)
+		for j, field := range mp.Field {
+			if field.JsonName == nil {
+				jsonName := m.Fields().Get(j).JSONName()
+				field.JsonName = &jsonName
+			}
+		}
+		setJSONNames(m.Messages(), mp.NestedType)
+		setExtensionJSONNames(m.Extensions(), mp.Extension)
+	}
+}
+
+func setExtensionJSONNames(extensions protoreflect.ExtensionDescriptors, protos []*descriptorpb.FieldDescriptorProto) {
+	for i, field := range protos {
+		if field.JsonName == nil {
+			jsonName := extensions.Get(i).JSONName()
+			field.JsonName = &jsonName
+		}
+	}
+}
+
+// imageFileExtensionField is the number of the buf_extension field that buf
+// images add to google.protobuf.FileDescriptorProto, and imageIsImportField
+// the one of its is_import field. Buf images are otherwise encoded as a
+// google.protobuf.FileDescriptorSet.
+const (
+	imageFileExtensionField = 8042
+	imageIsImportField      = 1
+)
+
+// DescriptorSetRequest reads a serialized FileDescriptorSet, e.g. written by
+// protoc -o, or a buf image, and returns the request protoc would send to the
+// plugin for the given files. Without files, the files that are not imports
+// of the buf image are generated, or all of them for a FileDescriptorSet.
+func DescriptorSetRequest(path string, files []string) (*pluginpb.CodeGeneratorRequest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, set); err != nil {
+		return nil, fmt.Errorf("failed to parse the descriptor set %s: %w", path, err)
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{FileToGenerate: files, ProtoFile: set.File}
+	known := map[string]struct{}{}
+	for _, fd := range set.File {
+		known[fd.GetName()] = struct{}{}
+		if len(files) == 0 && !isImageImport(fd) {
+			req.FileToGenerate = append(req.FileToGenerate, fd.GetName())
+		}
+	}
+	for _, name := range req.FileToGenerate {
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("%s is not in the descriptor set %s", name, path)
+		}
+	}
+	for _, fd := range set.File {
+		if fd.SourceCodeInfo == nil && stringSliceContains(req.FileToGenerate, fd.GetName()) {
+			glog.Warningf("no source info for %s, build the descriptor set with --include_source_info", fd.GetName())
+		}
+	}
+	return req, nil
+}
+
+// isImageImport reports whether a file of a buf image is only there as an
+// import of the other files.
+func isImageImport(fd *descriptorpb.FileDescriptorProto) bool {
+	isImport := false
+	forEachField(fd.ProtoReflect().GetUnknown(), func(num protowire.Number, v []byte) {
+		if num != imageFileExtensionField {
+			return
+		}
+		for len(v) > 0 {
+			num, typ, n := protowire.ConsumeTag(v)
+			if n < 0 {
+				return
+			}
+			v = v[n:]
+			if num == imageIsImportField && typ == protowire.VarintType {
+				value, _ := protowire.ConsumeVarint(v)
+				isImport = value != 0
+			}
+			if n = protowire.ConsumeFieldValue(num, typ, v); n < 0 {
+				return
+			}
+			v = v[n:]
+		}
+	})
+	return isImport
+}
@@ -0,0 +1,191 @@
+package partial
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestCompileRequest(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "protos"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"protos/message.proto": `syntax = "proto3";
+package protos;
+option go_package = "./proto";
+message CommonMessage { string my_string = 1; }
+`,
+		"protos/Go_A.proto": `syntax = "proto3";
+package protos;
+option go_package = "./proto";
+import "protos/message.proto";
+import "google/protobuf/empty.proto";
+service Go_A {
+  // Go_A_1 is documented.
+  rpc Go_A_1(CommonMessage) returns (google.protobuf.Empty);
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req, err := CompileRequest(context.Background(), []string{root}, []string{"protos/Go_A.proto"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := []string{}
+	for _, fd := range req.ProtoFile {
+		names = append(names, fd.GetName())
+	}
+	if len(names) != 3 || names[0] != "protos/message.proto" || names[1] != "google/protobuf/empty.proto" {
+		t.Fatalf("expected the dependencies in import order before protos/Go_A.proto, got %v", names)
+	}
+	if got := req.ProtoFile[0].MessageType[0].Field[0].GetJsonName(); got != "myString" {
+		t.Errorf("expected the JSON name myString, got %q", got)
+	}
+	if req.ProtoFile[2].SourceCodeInfo == nil {
+		t.Errorf("expected the source info of protos/Go_A.proto")
+	}
+
+	if _, err := CompileRequest(context.Background(), []string{root}, []string{"protos/missing.proto"}); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestDescriptorSetRequest(t *testing.T) {
+	fd := newTestFileDescriptor("Go_A", "Go_A_1")
+	dep := &descriptorpb.FileDescriptorProto{Name: proto.String("protos/dep.proto"), Package: proto.String("protos")}
+	ext := protowire.AppendVarint(protowire.AppendTag(nil, imageIsImportField, protowire.VarintType), 1)
+	raw := protowire.AppendTag(nil, imageFileExtensionField, protowire.BytesType)
+	dep.ProtoReflect().SetUnknown(protowire.AppendBytes(raw, ext))
+
+	b, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{dep, fd}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "image.binpb")
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := DescriptorSetRequest(path, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(req.FileToGenerate) != 1 || req.FileToGenerate[0] != "protos/Go_A.proto" || len(req.ProtoFile) != 2 {
+		t.Errorf("expected the imports of the image not to be generated, got %v", req.FileToGenerate)
+	}
+	if req, err = DescriptorSetRequest(path, []string{"protos/dep.proto"}); err != nil || req.FileToGenerate[0] != "protos/dep.proto" {
+		t.Errorf("expected the given files to be generated, got %v, %v", req, err)
+	}
+	if _, err := DescriptorSetRequest(path, []string{"protos/missing.proto"}); err == nil {
+		t.Errorf("expected an error for a file missing from the set")
+	}
+}
+
+func TestDescriptorSetMatchesCompiledRequest(t *testing.T) {
+	compiled := compileTestdata(t)
+	// like protoc -o, the descriptor set does not hold the JSON names.
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range compiled.ProtoFile {
+		fd = proto.Clone(fd).(*descriptorpb.FileDescriptorProto)
+		clearJSONNames(fd.MessageType)
+		set.File = append(set.File, fd)
+	}
+	b, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "protos.binpb")
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := DescriptorSetRequest(path, testdataFiles)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := linkTestdata(t, compiled, testdataParams())
+	if got := linkTestdata(t, req, testdataParams()); !bytes.Equal(got, want) {
+		t.Errorf("expected the index linked from the descriptor set to be the one of the compiled proto files")
+	}
+}
+
+func clearJSONNames(messages []*descriptorpb.DescriptorProto) {
+	for _, m := range messages {
+		for _, field := range m.Field {
+			field.JsonName = nil
+		}
+		clearJSONNames(m.NestedType)
+	}
+}
+
+func TestLinkProto2WithoutPackage(t *testing.T) {
+	root := t.TempDir()
+	content := `syntax = "proto2";
+option go_package = "./svc";
+message Req { optional string name = 1; }
+service Svc { rpc Get(Req) returns (Req); }
+`
+	if err := os.WriteFile(filepath.Join(root, "svc.proto"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	req, err := CompileRequest(context.Background(), []string{root}, []string{"svc.proto"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	index := unmarshalIndex(t, linkTestdata(t, req, testdataParams()))
+	const service = "scip-proto proto . proto2 svc/svc/Svc#"
+	found := false
+	for _, d := range index.Documents {
+		for _, si := range d.Symbols {
+			found = found || si.Symbol == service
+		}
+	}
+	if !found {
+		t.Errorf("expected the symbol %s of the proto2 service", service)
+	}
+}
+
+func TestLinkDefaultScipDir(t *testing.T) {
+	req := compileTestdata(t)
+	params := testdataParams()
+	params.ScipDirs = nil
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("../scip/testdata"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	resp, err := Link(req, params)
+	if err != nil || resp.Error != nil || len(resp.File) != 1 {
+		t.Errorf("expected the indexes of the working directory to be linked, got %v, %v", resp.GetError(), err)
+	}
+}
+
+func TestLinkMissingScipDir(t *testing.T) {
+	params := testdataParams()
+	params.ScipDirs = []string{filepath.Join(t.TempDir(), "missing")}
+	resp, err := Link(compileTestdata(t), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(resp.GetError(), "failed to scan the directory") {
+		t.Errorf("expected the scan error in the response, got %q", resp.GetError())
+	}
+}
@@ -0,0 +1,143 @@
+package partial
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest describes the indexes to link, e.g. when they were built on
+// different machines and their project roots are not below the source root.
+//
+//	projects:
+//	  - index: Go_A.scip
+//	    name: Go_A
+//	    root: /src/Go_A
+//	    namespace: Go_A
+//	  - index: web/tsA.scip.gz
+//	    name: Ts_A
+//	    root: /src/web
+//	    namespace: ""
+//
+// The relative paths are relative to the directory of the manifest. The
+// indexes it lists are linked in addition to the ones found in the scip
+// directories.
+type Manifest struct {
+	Projects []*ManifestProject `yaml:"projects"`
+}
+
+// ManifestProject describes a single index.
+type ManifestProject struct {
+	// Index is the path of the index file.
+	Index string `yaml:"index"`
+	// Name is the logical name of the project, its project root is used if
+	// it is empty.
+	Name string `yaml:"name"`
+	// Root replaces the project root recorded in the index, the paths of its
+	// documents being relative to it.
+	Root string `yaml:"root"`
+	// Namespace is the namespace the symbols of the index are rewritten
+	// with, see Rewrite. If it is not set, the path of the project root
+	// relative to the source root is used, and an empty namespace leaves the
+	// symbols unchanged.
+	Namespace *string `yaml:"namespace"`
+}
+
+// LoadManifest reads a Manifest from a YAML file.
+func LoadManifest(path string) (*Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := yaml.Unmarshal(content, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %v", path, err)
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range m.Projects {
+		if p.Index == "" {
+			return nil, fmt.Errorf("invalid manifest %s: no index for project %d", path, i)
+		}
+		p.Index = resolvePath(dir, p.Index)
+		if p.Root != "" {
+			p.Root = resolvePath(dir, p.Root)
+		}
+	}
+	return m, nil
+}
+
+func resolvePath(dir string, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
+
+// project returns the description of the index file at path, if any.
+func (m *Manifest) project(path string) *ManifestProject {
+	if m == nil {
+		return nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	for _, p := range m.Projects {
+		if p.Index == abs {
+			return p
+		}
+	}
+	return nil
+}
+
+// addIndexes appends the indexes of the manifest missing from files.
+func (m *Manifest) addIndexes(files []string) []string {
+	if m == nil {
+		return files
+	}
+	for _, p := range m.Projects {
+		found := false
+		for _, f := range files {
+			if abs, err := filepath.Abs(f); err == nil && abs == p.Index {
+				found = true
+				break
+			}
+		}
+		if !found {
+			files = append(files, p.Index)
+		}
+	}
+	return files
+}
+
+// root returns the project root of the index, as given by the manifest or as
+// recorded in the index.
+func (p *ManifestProject) root(recorded string) string {
+	if p != nil && p.Root != "" {
+		return p.Root
+	}
+	return removePrefix(recorded)
+}
+
+// name returns the name of the project, or its root if the manifest does not
+// name it.
+func (p *ManifestProject) name(root string) string {
+	if p != nil && p.Name != "" {
+		return p.Name
+	}
+	return root
+}
+
+// namespace returns the namespace prefixed to the symbols of the index, and
+// whether the manifest sets it.
+func (p *ManifestProject) namespace() (string, bool) {
+	if p == nil || p.Namespace == nil {
+		return "", false
+	}
+	return *p.Namespace, true
+}
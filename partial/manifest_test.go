@@ -0,0 +1,72 @@
+package partial
+
+import (
+	"os"
+	"path/filepath"
+	"protoc-gen-scip/scip"
+	"sync"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const testManifest = `
+projects:
+  - index: Go_A.scip
+    name: Go_A
+    root: /src/Go_A
+    namespace: backend
+  - index: /abs/tsA.scip
+`
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "projects.yaml")
+	if err := os.WriteFile(manifestPath, []byte(testManifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	indexPath := filepath.Join(dir, "Go_A.scip")
+	if files := m.addIndexes([]string{indexPath}); len(files) != 2 || files[1] != "/abs/tsA.scip" {
+		t.Errorf("expected the missing index of the manifest to be added, got %v", files)
+	}
+	project := m.project(indexPath)
+	if project == nil || project.Root != "/src/Go_A" {
+		t.Fatalf("expected the project of %s, got %v", indexPath, project)
+	}
+
+	// the index was built on another machine.
+	index := &scip.Index{
+		Metadata: &scip.Metadata{ProjectRoot: "file:///home/nn/RPCoverBenchmark/Go_A"},
+		Documents: []*scip.Document{{
+			RelativePath: "proto/Go_A_grpc.pb.go",
+			Symbols:      []*scip.SymbolInformation{{Symbol: "scip-go gomod Go_A cb6b82253d24 Go_A/proto/Go_AServer#"}},
+		}},
+	}
+	b, err := proto.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(indexPath, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	initIndexes(1, "")
+	var wg sync.WaitGroup
+	wg.Add(1)
+	indexScipFile(0, indexPath, "/src", project, &wg)
+
+	d := indexes[0].Documents[0]
+	if d.RelativePath != "Go_A/proto/Go_A_grpc.pb.go" {
+		t.Errorf("expected the document to be rebased to /src/Go_A, got %s", d.RelativePath)
+	}
+	if got, want := d.Symbols[0].Symbol, "scip-go gomod Go_A cb6b82253d24 backend/Go_A/proto/Go_AServer#"; got != want {
+		t.Errorf("got symbol %s, want %s", got, want)
+	}
+	if projects[0] != "Go_A" {
+		t.Errorf("expected the project to be named Go_A, got %s", projects[0])
+	}
+}
@@ -0,0 +1,170 @@
+package partial
+
+import (
+	"fmt"
+	"os"
+	"protoc-gen-scip/scip"
+
+	"github.com/golang/glog"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gopkg.in/yaml.v3"
+)
+
+// Mapping declares the links that the matchers can not find, e.g. for hand
+// written servers, and the links they should not make.
+//
+//	services:
+//	  protos.Go_A:
+//	    symbols:
+//	      - scip-go gomod Go_A cb6b82253d24 Go_A/cmd/server#
+//	    methods:
+//	      Go_A_1:
+//	        - scip-go gomod Go_A cb6b82253d24 Go_A/cmd/server#Go_A_1().
+//	    exclude:
+//	      - scip-go gomod Go_A cb6b82253d24 Go_A/cmd/mock#
+//	exclude:
+//	  - scip-go gomod Go_A cb6b82253d24 Go_A/test/fake#
+//
+// The symbols are written either as in the input indexes or as in the
+// generated one.
+type Mapping struct {
+	// Services maps the full names of the proto services to their links.
+	Services map[string]*ServiceMapping `yaml:"services"`
+	// Exclude lists the symbols that are never linked to any service.
+	Exclude []string `yaml:"exclude"`
+}
+
+// ServiceMapping declares the links of a single service.
+type ServiceMapping struct {
+	// Symbols are linked to the service, in addition to the matched ones.
+	Symbols []string `yaml:"symbols"`
+	// Methods maps the proto method names to the symbols linked to them. They
+	// replace the matched symbols of these methods.
+	Methods map[string][]string `yaml:"methods"`
+	// Exclude lists the symbols that are never linked to the service.
+	Exclude []string `yaml:"exclude"`
+}
+
+// mapping is the Mapping given to GenerateFile, it may be nil.
+var mapping *Mapping
+
+// symbolInfos indexes the symbols of each input index by their original and
+// rewritten names, so that the symbols of the mapping can be resolved. Each
+// indexing goroutine only fills the map of its index.
+var symbolInfos []map[string]*scip.SymbolInformation
+
+// LoadMapping reads a Mapping from a YAML file.
+func LoadMapping(path string) (*Mapping, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Mapping{}
+	if err := yaml.Unmarshal(content, m); err != nil {
+		return nil, fmt.Errorf("invalid mapping %s: %v", path, err)
+	}
+	return m, nil
+}
+
+func (m *Mapping) service(s *protogen.Service) *ServiceMapping {
+	if m == nil {
+		return nil
+	}
+	return m.Services[string(s.Desc.FullName())]
+}
+
+// excludes reports whether si must not be linked to s.
+func (m *Mapping) excludes(s *protogen.Service, si *scip.SymbolInformation) bool {
+	if m == nil {
+		return false
+	}
+	excluded := m.Exclude
+	if sm := m.service(s); sm != nil {
+		excluded = append(append([]string{}, excluded...), sm.Exclude...)
+	}
+	for _, symbol := range excluded {
+		for _, excluded := range resolveSymbols(symbol) {
+			if excluded == si {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// overrides reports whether the symbols of method are declared in the mapping.
+func (m *Mapping) overrides(method *protogen.Method) bool {
+	sm := m.service(method.Parent)
+	if sm == nil {
+		return false
+	}
+	_, ok := sm.Methods[string(method.Desc.Name())]
+	return ok
+}
+
+// filterMapping removes the excluded and overridden symbols from siMap.
+func filterMapping(s *protogen.Service, siMap map[*scip.SymbolInformation]string) {
+	if mapping == nil {
+		return
+	}
+	overridden := map[string]struct{}{}
+	for _, m := range s.Methods {
+		if mapping.overrides(m) {
+			overridden[getMethodKey(m)] = struct{}{}
+		}
+	}
+	for si, key := range siMap {
+		if _, ok := overridden[key]; ok || mapping.excludes(s, si) {
+			delete(siMap, si)
+		}
+	}
+}
+
+// applyMapping links the symbols declared in the mapping to the service and
+// its methods. It reports whether any symbol was linked.
+func applyMapping(s *protogen.Service, symbols map[string]*scip.SymbolInformation) (map[string][]*scip.Relationship, bool) {
+	sm := mapping.service(s)
+	if sm == nil {
+		return nil, false
+	}
+
+	siMap := map[*scip.SymbolInformation]string{}
+	resolve := func(symbol string, key string) {
+		resolved := resolveSymbols(symbol)
+		if len(resolved) == 0 {
+			glog.Errorf("symbol %s of the mapping of %s not found in the indexes", symbol, s.Desc.FullName())
+		}
+		for _, si := range resolved {
+			siMap[si] = key
+		}
+	}
+	for _, symbol := range sm.Symbols {
+		resolve(symbol, getServiceKey(s))
+	}
+	for _, m := range s.Methods {
+		for _, symbol := range sm.Methods[string(m.Desc.Name())] {
+			resolve(symbol, getMethodKey(m))
+		}
+	}
+	for name := range sm.Methods {
+		if s.Desc.Methods().ByName(protoreflect.Name(name)) == nil {
+			glog.Errorf("method %s of the mapping not found in %s", name, s.Desc.FullName())
+		}
+	}
+
+	return linkSymbols(siMap, symbols, map[string][]*scip.Relationship{}), len(siMap) > 0
+}
+
+// resolveSymbols returns the symbols named symbol in the input indexes, in
+// the order of the indexes, as an original symbol may be defined by several
+// of them, e.g. by the indexes of a module built under different roots.
+func resolveSymbols(symbol string) []*scip.SymbolInformation {
+	res := []*scip.SymbolInformation{}
+	for _, infos := range symbolInfos {
+		if si, ok := infos[symbol]; ok {
+			res = append(res, si)
+		}
+	}
+	return res
+}
@@ -0,0 +1,71 @@
+package partial
+
+import (
+	"os"
+	"path/filepath"
+	"protoc-gen-scip/scip"
+	"testing"
+)
+
+const testMapping = `
+services:
+  protos.Go_A:
+    symbols:
+      - scip-go gomod Go_A cb6b82253d24 Go_A/cmd/server#
+    methods:
+      Go_A_1:
+        - scip-go gomod Go_A cb6b82253d24 Go_A/cmd/server#Go_A_1().
+    exclude:
+      - scip-go gomod Go_A cb6b82253d24 Go_A/proto/UnsafeGo_AServer#
+`
+
+func TestMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "links.yaml")
+	if err := os.WriteFile(path, []byte(testMapping), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadMapping(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mapping = m
+	defer func() { mapping = nil }()
+	initIndexes(2, "")
+
+	f := newTestFile(t, "Go_A", "Go_A_1", "Go_A_2")
+	s := f.Services[0]
+	server := &scip.SymbolInformation{Symbol: "scip-go gomod Go_A cb6b82253d24 Go_A/cmd/server#"}
+	method := &scip.SymbolInformation{Symbol: "scip-go gomod Go_A cb6b82253d24 Go_A/cmd/server#Go_A_1()."}
+	unsafe := &scip.SymbolInformation{Symbol: "scip-go gomod Go_A cb6b82253d24 Go_A/proto/UnsafeGo_AServer#"}
+	matched := &scip.SymbolInformation{Symbol: "scip-go gomod Go_A cb6b82253d24 Go_A/proto/Go_AServer#Go_A_1."}
+	kept := &scip.SymbolInformation{Symbol: "scip-go gomod Go_A cb6b82253d24 Go_A/proto/Go_AServer#Go_A_2."}
+	for _, si := range []*scip.SymbolInformation{server, method, unsafe, matched, kept} {
+		symbolInfos[0][si.Symbol] = si
+	}
+	// the same module indexed under another root.
+	otherMethod := &scip.SymbolInformation{Symbol: method.Symbol}
+	symbolInfos[1][otherMethod.Symbol] = otherMethod
+
+	siMap := map[*scip.SymbolInformation]string{
+		unsafe:  getServiceKey(s),
+		matched: getMethodKey(s.Methods[0]),
+		kept:    getMethodKey(s.Methods[1]),
+	}
+	filterMapping(s, siMap)
+	if len(siMap) != 1 || siMap[kept] == "" {
+		t.Errorf("expected the excluded and overridden symbols to be removed, got %v", siMap)
+	}
+
+	symbols := generateService(f, s, &scip.Document{})
+	if _, ok := applyMapping(s, symbols); !ok {
+		t.Fatalf("expected the mapping to link symbols")
+	}
+	for _, si := range []*scip.SymbolInformation{method, otherMethod} {
+		if len(si.Relationships) != 1 || si.Relationships[0].Symbol != symbols[getMethodKey(s.Methods[0])].Symbol {
+			t.Errorf("expected every %s to be linked to Go_A_1, got %v", si.Symbol, si.Relationships)
+		}
+	}
+	if _, ok := whiteListedSymbols.Load(server.Symbol); !ok {
+		t.Errorf("expected %s to be whitelisted", server.Symbol)
+	}
+}
@@ -0,0 +1,301 @@
+package partial
+
+import (
+	"fmt"
+	"path"
+	"protoc-gen-scip/scip"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// Matcher decides whether a type found in a SCIP index belongs to a proto
+// service, e.g. because it was generated from it or implements it.
+//
+// Users relying on their own gRPC plugins can register a Matcher that knows
+// about their naming conventions, see RegisterMatcher.
+type Matcher interface {
+	// Match returns the symbols of t that correspond to the methods of s.
+	// A nil result means that t is not related to s.
+	Match(s *protogen.Service, t *ScipType) *MatchResult
+}
+
+// Scoper is implemented by the matchers knowing where the code generated for a
+// proto file lives. Only the types in scope, or implementing a type in scope,
+// are then given to Match, unless unscoped matching is allowed.
+type Scoper interface {
+	// InScope reports whether the namespaces scope, as written in the SCIP
+	// symbols and joined with slashes, hold code generated for f. The
+	// namespaces may be prefixed with the path of the project.
+	InScope(f *protogen.File, scope string) bool
+}
+
+// MatchResult is the outcome of a successful Matcher.Match call.
+type MatchResult struct {
+	// Service holds the symbols related to the service itself. The symbol of
+	// the matched type is used if it is empty.
+	Service []*scip.SymbolInformation
+	// Methods holds the matched method symbols for each method of the service.
+	Methods map[*protogen.Method][]*scip.SymbolInformation
+	// Streams holds the matched stream wrappers of the streaming methods.
+	Streams []*StreamMatch
+	// Routes holds the matched handlers of the HTTP bindings of the methods.
+	Routes []*RouteMatch
+	// Score ranks the results of several matchers for the same type, the
+	// highest score wins.
+	Score int
+	// Client is set if the matched type is a client stub. The call sites of
+	// its methods are then linked to the proto methods.
+	Client bool
+}
+
+var (
+	matchersMu sync.RWMutex
+	matchers   = map[string]Matcher{}
+)
+
+// RegisterMatcher makes a Matcher available under the given name, so that it
+// can be selected with the matcher parameter of the plugin.
+// It panics if a matcher with the same name is already registered.
+func RegisterMatcher(name string, m Matcher) {
+	matchersMu.Lock()
+	defer matchersMu.Unlock()
+	if _, ok := matchers[name]; ok {
+		panic(fmt.Sprintf("partial: matcher %q registered twice", name))
+	}
+	matchers[name] = m
+}
+
+// MatcherNames returns the names of all registered matchers in sorted order.
+func MatcherNames() []string {
+	matchersMu.RLock()
+	defer matchersMu.RUnlock()
+	names := make([]string, 0, len(matchers))
+	for name := range matchers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupMatchers returns the matchers registered under the given names.
+// If no name is given, every registered matcher is returned.
+func LookupMatchers(names []string) ([]Matcher, error) {
+	if len(names) == 0 {
+		names = MatcherNames()
+	}
+	matchersMu.RLock()
+	defer matchersMu.RUnlock()
+	res := make([]Matcher, 0, len(names))
+	for _, name := range names {
+		m, ok := matchers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown matcher %q", name)
+		}
+		res = append(res, m)
+	}
+	return res, nil
+}
+
+// inScope reports whether t is in the scope of f for matcher, see Scoper.
+func inScope(matcher Matcher, f *protogen.File, t *ScipType) bool {
+	scoper, ok := matcher.(Scoper)
+	if !ok || scoper.InScope(f, t.Scope()) {
+		return true
+	}
+	for _, rel := range t.TypeSymbol.Relationships {
+		if rel.IsImplementation && scoper.InScope(f, symbolScope(rel.Symbol)) {
+			return true
+		}
+	}
+	return false
+}
+
+// markGeneratedSymbols marks the relationships of the symbols of the
+// generated code, i.e. the ones in the scope of the matcher, as definitions of
+// their proto symbols: the proto files define them, and they only implement
+// the proto methods for the code that implements them in turn.
+func markGeneratedSymbols(matcher Matcher, f *protogen.File, siMap map[*scip.SymbolInformation]string, symbols map[string]*scip.SymbolInformation) {
+	scoper, ok := matcher.(Scoper)
+	if !ok {
+		return
+	}
+	for si, key := range siMap {
+		if !scoper.InScope(f, symbolScope(si.Symbol)) {
+			continue
+		}
+		for _, rel := range si.Relationships {
+			if rel.Symbol == symbols[key].Symbol {
+				rel.IsDefinition = true
+			}
+		}
+	}
+}
+
+// symbolScope returns the namespaces of a symbol joined with slashes.
+func symbolScope(symbol string) string {
+	sym, err := scip.ParseSymbol(symbol)
+	if err != nil {
+		return ""
+	}
+	namespaces := []string{}
+	for _, desc := range sym.Descriptors {
+		if desc.Suffix == scip.Descriptor_Namespace {
+			namespaces = append(namespaces, desc.Name)
+		}
+	}
+	return strings.Join(namespaces, "/")
+}
+
+// hasPathSuffix reports whether the slash separated path ends with suffix.
+func hasPathSuffix(path string, suffix string) bool {
+	return path == suffix || strings.HasSuffix(path, "/"+suffix)
+}
+
+// implementsType reports whether si implements or extends a type accepted by
+// match, as recorded by the indexers in the relationships of si. match is
+// given the symbol of the type and its descriptors.
+func implementsType(si *scip.SymbolInformation, match func(symbol string, descriptors []*scip.Descriptor) bool) bool {
+	for _, rel := range si.Relationships {
+		if !rel.IsImplementation {
+			continue
+		}
+		sym, err := scip.ParseSymbol(rel.Symbol)
+		if err != nil || len(sym.Descriptors) == 0 {
+			continue
+		}
+		if sym.Descriptors[len(sym.Descriptors)-1].Suffix == scip.Descriptor_Type && match(rel.Symbol, sym.Descriptors) {
+			return true
+		}
+	}
+	return false
+}
+
+// namedOneOf matches the types with one of the given names, for
+// implementsType.
+func namedOneOf(names []string) func(string, []*scip.Descriptor) bool {
+	return func(_ string, descriptors []*scip.Descriptor) bool {
+		return stringSliceContains(names, descriptors[len(descriptors)-1].Name)
+	}
+}
+
+func init() {
+	RegisterMatcher("go-grpc", goGrpcMatcher{})
+}
+
+// goGrpcMatcher matches the code generated by protoc-gen-go-grpc, that is
+//   - the <Service>Server and <Service>Client interfaces,
+//   - the Unimplemented<Service>Server, Unsafe<Service>Server and
+//     <service>Client types,
+//   - the types implementing one of the interfaces above,
+//   - the stream wrappers of the streaming methods, see matchGoStream,
+//   - the grpc-gateway handlers of the HTTP bindings, see matchGoGateway.
+//
+// The names are compared for equality with the Go identifiers derived by
+// protogen, see goGrpcFuzzyMatcher for a looser comparison.
+type goGrpcMatcher struct{}
+
+func (goGrpcMatcher) Match(s *protogen.Service, t *ScipType) *MatchResult {
+	if !strings.HasPrefix(t.TypeSymbol.Symbol, "scip-go ") {
+		return nil
+	}
+	if t.Module {
+		return matchGoGateway(s, t)
+	}
+	if res := matchGoStream(s, t); res != nil {
+		return res
+	}
+	names := goGrpcTypeNames(s.GoName)
+	if !stringSliceContains(names, t.ShortName()) && !implementsType(t.TypeSymbol, namedOneOf(names)) {
+		return nil
+	}
+
+	short := t.ShortName()
+	res := &MatchResult{
+		Methods: map[*protogen.Method][]*scip.SymbolInformation{},
+		Score:   2,
+		Client:  short == s.GoName+"Client" || short == lowerFirst(s.GoName)+"Client",
+	}
+	for _, m := range s.Methods {
+		if matches := t.findMembers(m.GoName); len(matches) > 0 {
+			res.Methods[m] = matches
+		}
+	}
+	return res
+}
+
+// InScope matches the Go import path of the generated package. The relative
+// import paths, e.g. go_package = "./proto", are matched as a suffix of the
+// package path.
+func (goGrpcMatcher) InScope(f *protogen.File, scope string) bool {
+	return goPackageInScope(f, scope)
+}
+
+func goPackageInScope(f *protogen.File, scope string) bool {
+	return goImportPathInScope(f.GoImportPath, scope)
+}
+
+func goImportPathInScope(goImportPath protogen.GoImportPath, scope string) bool {
+	importPath := path.Clean(string(goImportPath))
+	for strings.HasPrefix(importPath, "../") {
+		importPath = importPath[len("../"):]
+	}
+	return importPath != "." && hasPathSuffix(scope, importPath)
+}
+
+// MatchMessage matches the struct generated by protoc-gen-go for m, its fields
+// and their getters.
+func (goGrpcMatcher) MatchMessage(m *protogen.Message, t *ScipType) *MessageMatchResult {
+	if !strings.HasPrefix(t.TypeSymbol.Symbol, "scip-go ") || t.ShortName() != m.GoIdent.GoName || !goImportPathInScope(m.GoIdent.GoImportPath, t.Scope()) {
+		return nil
+	}
+	res := &MessageMatchResult{Fields: map[*protogen.Field][]*scip.SymbolInformation{}, Score: 2}
+	for _, field := range m.Fields {
+		if matches := append(t.findMembers(field.GoName), t.findMembers("Get"+field.GoName)...); len(matches) > 0 {
+			res.Fields[field] = matches
+		}
+	}
+	return res
+}
+
+// goGrpcTypeNames returns the names of the types generated for a service.
+func goGrpcTypeNames(service string) []string {
+	return []string{
+		service + "Server",
+		service + "Client",
+		"Unimplemented" + service + "Server",
+		"Unsafe" + service + "Server",
+		lowerFirst(service) + "Client",
+	}
+}
+
+// goGrpcFuzzyMatcher is the legacy matcher, i.e. every type whose name
+// contains the service name and whose method set covers all the methods of
+// the service. It is only used as a fallback, see Options.Fuzzy.
+type goGrpcFuzzyMatcher struct{}
+
+func (goGrpcFuzzyMatcher) InScope(f *protogen.File, scope string) bool {
+	return goPackageInScope(f, scope)
+}
+
+func (goGrpcFuzzyMatcher) Match(s *protogen.Service, t *ScipType) *MatchResult {
+	if t.Module || !matchName(t.TypeName(), s.GoName) {
+		return nil
+	}
+
+	res := &MatchResult{
+		Methods: map[*protogen.Method][]*scip.SymbolInformation{},
+		Score:   1,
+		Client:  strings.HasSuffix(t.ShortName(), "Client"),
+	}
+	for _, m := range s.Methods {
+		matches := t.findMethods(m.GoName)
+		if len(matches) == 0 {
+			return nil
+		}
+		res.Methods[m] = matches
+	}
+	return res
+}
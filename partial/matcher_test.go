@@ -0,0 +1,202 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// newTestFile builds the protogen.File of a proto file declaring a single
+// service with the given methods, all of them taking and returning Empty.
+func newTestFile(t *testing.T, service string, methods ...string) *protogen.File {
+	t.Helper()
+	return newTestFileFromDescriptor(t, newTestFileDescriptor(service, methods...))
+}
+
+func newTestFileDescriptor(service string, methods ...string) *descriptorpb.FileDescriptorProto {
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("protos/" + service + ".proto"),
+		Package: proto.String("protos"),
+		Syntax:  proto.String("proto3"),
+		Options: &descriptorpb.FileOptions{GoPackage: proto.String("./proto")},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Empty")},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{Name: proto.String(service)},
+		},
+	}
+	for _, m := range methods {
+		fd.Service[0].Method = append(fd.Service[0].Method, &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(m),
+			InputType:  proto.String(".protos.Empty"),
+			OutputType: proto.String(".protos.Empty"),
+		})
+	}
+	return fd
+}
+
+func newTestFileFromDescriptor(t *testing.T, fd *descriptorpb.FileDescriptorProto) *protogen.File {
+	t.Helper()
+	gen, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{fd.GetName()},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{fd},
+	})
+	if err != nil {
+		t.Fatalf("failed to create the plugin: %v", err)
+	}
+	return gen.Files[0]
+}
+
+// newTestType builds a ScipType from SCIP symbols, the first one being the
+// symbol of the type itself.
+func newTestType(t *testing.T, symbols ...string) *ScipType {
+	t.Helper()
+	initIndexes(1, "")
+	for _, s := range symbols {
+		addScipTypeFromSymbolInformation(0, &scip.SymbolInformation{Symbol: s}, "")
+	}
+	if len(typeMaps[0]) != 1 {
+		t.Fatalf("expected symbols of a single type, got %d types", len(typeMaps[0]))
+	}
+	for _, st := range typeMaps[0] {
+		return st
+	}
+	return nil
+}
+
+func TestLookupMatchers(t *testing.T) {
+	all, err := LookupMatchers(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != len(MatcherNames()) {
+		t.Errorf("expected every registered matcher, got %d out of %d", len(all), len(MatcherNames()))
+	}
+
+	if _, err := LookupMatchers([]string{"go-grpc"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := LookupMatchers([]string{"go-grpc", "unknown"}); err == nil {
+		t.Errorf("expected an error for an unknown matcher")
+	}
+}
+
+const goPackage = "scip-go gomod Go_A cb6b82253d24 Go_A/proto/"
+
+func TestGoGrpcMatcher(t *testing.T) {
+	f := newTestFile(t, "Go_A", "Go_A_1", "Go_A_2")
+	s := f.Services[0]
+
+	server := newTestType(t,
+		goPackage+"Go_AServer#",
+		goPackage+"Go_AServer#Go_A_1.",
+		goPackage+"Go_AServer#Go_A_2.",
+	)
+	res := goGrpcMatcher{}.Match(s, server)
+	if res == nil {
+		t.Fatalf("expected Go_AServer to match")
+	}
+	if res.Client {
+		t.Errorf("expected Go_AServer not to be a client")
+	}
+	for _, m := range s.Methods {
+		if len(res.Methods[m]) != 1 {
+			t.Errorf("expected a single symbol for %s, got %v", m.GoName, res.Methods[m])
+		}
+	}
+
+	client := newTestType(t,
+		goPackage+"go_AClient#",
+		goPackage+"go_AClient#Go_A_1().",
+	)
+	if res := (goGrpcMatcher{}).Match(s, client); res == nil || !res.Client {
+		t.Errorf("expected go_AClient to match as a client, got %v", res)
+	}
+
+	prefixed := newTestType(t,
+		goPackage+"Go_ABServer#",
+		goPackage+"Go_ABServer#Go_A_1.",
+		goPackage+"Go_ABServer#Go_A_2.",
+	)
+	if res := (goGrpcMatcher{}).Match(s, prefixed); res != nil {
+		t.Errorf("expected Go_ABServer not to match, got %v", res)
+	}
+
+	longer := newTestType(t,
+		goPackage+"Go_AServer#",
+		goPackage+"Go_AServer#Go_A_10.",
+		goPackage+"Go_AServer#Go_A_1Stream.",
+	)
+	if res := (goGrpcMatcher{}).Match(s, longer); res == nil || len(res.Methods) != 0 {
+		t.Errorf("expected no method to match, got %v", res)
+	}
+}
+
+func TestGoGrpcFuzzyMatcher(t *testing.T) {
+	f := newTestFile(t, "Go_A", "Go_A_1", "Go_A_2")
+	s := f.Services[0]
+
+	prefixed := newTestType(t,
+		goPackage+"Go_ABServer#",
+		goPackage+"Go_ABServer#GoA1.",
+		goPackage+"Go_ABServer#GoA2.",
+	)
+	if res := (goGrpcFuzzyMatcher{}).Match(s, prefixed); res == nil {
+		t.Errorf("expected Go_ABServer to match")
+	}
+
+	incomplete := newTestType(t,
+		goPackage+"Go_AServer#",
+		goPackage+"Go_AServer#Go_A_1.",
+	)
+	if res := (goGrpcFuzzyMatcher{}).Match(s, incomplete); res != nil {
+		t.Errorf("expected a type missing methods not to match, got %v", res)
+	}
+}
+
+func TestInScope(t *testing.T) {
+	f := newTestFile(t, "Go_A", "Go_A_1")
+
+	tests := []struct {
+		matcher Scoper
+		scope   string
+		want    bool
+	}{
+		{goGrpcMatcher{}, "Go_A/proto", true},
+		{goGrpcMatcher{}, "../Go_A/Go_A/proto", true},
+		{goGrpcMatcher{}, "Go_A/otherproto", false},
+		{goGrpcMatcher{}, "Go_A/proto/v2", false},
+		{pythonGrpcMatcher{}, "protos.Go_A_pb2_grpc", true},
+		{pythonGrpcMatcher{}, "Python_A/app.protos.Go_A_pb2_grpc", true},
+		{pythonGrpcMatcher{}, "other.Go_A_pb2_grpc", false},
+		{tsGrpcMatcher{}, "Ts_A/protos/Go_A.ts", true},
+		{tsGrpcMatcher{}, "Ts_A/protos/Go_A_grpc_pb.d.ts", true},
+		{tsGrpcMatcher{}, "Ts_A/protos/Go_AB.ts", false},
+		{tsGrpcMatcher{}, "Ts_A/other/Go_A.ts", false},
+		{javaGrpcMatcher{}, "protos", true},
+		{javaGrpcMatcher{}, "com/example/protos", true},
+		{javaGrpcMatcher{}, "com/example", false},
+	}
+	for _, test := range tests {
+		if got := test.matcher.InScope(f, test.scope); got != test.want {
+			t.Errorf("%T.InScope(%q) = %v, want %v", test.matcher, test.scope, got, test.want)
+		}
+	}
+
+	impl := newTestType(t, "scip-go gomod Go_B cb6b82253d24 Go_B/cmd/server#")
+	impl.TypeSymbol.Relationships = []*scip.Relationship{
+		{Symbol: goPackage + "Go_AServer#", IsImplementation: true},
+	}
+	if !inScope(goGrpcMatcher{}, f, impl) {
+		t.Errorf("expected a type implementing Go_AServer to be in scope")
+	}
+	impl.TypeSymbol.Relationships = nil
+	if inScope(goGrpcMatcher{}, f, impl) {
+		t.Errorf("expected an unrelated type to be out of scope")
+	}
+}
@@ -0,0 +1,202 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MessageMatcher is implemented by the matchers that also link the proto
+// messages to the types generated for them.
+type MessageMatcher interface {
+	// MatchMessage returns the symbols of t that correspond to the fields of
+	// m. A nil result means that t was not generated for m.
+	MatchMessage(m *protogen.Message, t *ScipType) *MessageMatchResult
+}
+
+// MessageMatchResult is the outcome of a successful MessageMatcher.MatchMessage
+// call.
+type MessageMatchResult struct {
+	// Fields holds the matched symbols, e.g. the field and its getter, for
+	// each field of the message.
+	Fields map[*protogen.Field][]*scip.SymbolInformation
+	// Score ranks the results of several matchers for the same type.
+	Score int
+}
+
+// generateMessages adds the symbols of the messages and enums of f, including
+// the nested ones, their fields, oneofs and values. The symbols are returned
+// by full name.
+func generateMessages(f *protogen.File, d *scip.Document) map[string]*scip.SymbolInformation {
+	symbols := map[string]*scip.SymbolInformation{}
+	for _, e := range f.Enums {
+		generateEnum(f, e, d, symbols)
+	}
+	for _, m := range f.Messages {
+		generateMessage(f, m, d, symbols)
+	}
+	return symbols
+}
+
+func generateMessage(f *protogen.File, m *protogen.Message, d *scip.Document, symbols map[string]*scip.SymbolInformation) {
+	if m.Desc.IsMapEntry() {
+		return
+	}
+	symbols[string(m.Desc.FullName())] = generateDefinition(f, m.Desc, m.Location, scip.SymbolInformation_Message, documentation(messageSignature(m), m.Comments), d)
+	for _, field := range m.Fields {
+		symbols[string(field.Desc.FullName())] = generateDefinition(f, field.Desc, field.Location, scip.SymbolInformation_Field, documentation(fieldSignature(field), field.Comments), d)
+	}
+	for _, oneof := range m.Oneofs {
+		if oneof.Desc.IsSynthetic() {
+			continue
+		}
+		symbols[string(oneof.Desc.FullName())] = generateDefinition(f, oneof.Desc, oneof.Location, scip.SymbolInformation_Union, documentation(oneofSignature(oneof), oneof.Comments), d)
+	}
+	for _, e := range m.Enums {
+		generateEnum(f, e, d, symbols)
+	}
+	for _, nested := range m.Messages {
+		generateMessage(f, nested, d, symbols)
+	}
+}
+
+func generateEnum(f *protogen.File, e *protogen.Enum, d *scip.Document, symbols map[string]*scip.SymbolInformation) {
+	symbols[string(e.Desc.FullName())] = generateDefinition(f, e.Desc, e.Location, scip.SymbolInformation_Enum, documentation(enumSignature(e), e.Comments), d)
+	for _, v := range e.Values {
+		symbols[string(v.Desc.FullName())] = generateDefinition(f, v.Desc, v.Location, scip.SymbolInformation_EnumMember, documentation(enumValueSignature(v), v.Comments), d)
+	}
+}
+
+// linkMessages matches the messages of f against the types of every index and
+// returns the relations found in each of them.
+func linkMessages(f *protogen.File, matchers []Matcher, symbols map[string]*scip.SymbolInformation) []projectRelations {
+	messageMatchers := []MessageMatcher{}
+	for _, matcher := range matchers {
+		if mm, ok := matcher.(MessageMatcher); ok {
+			messageMatchers = append(messageMatchers, mm)
+		}
+	}
+	messages := []*protogen.Message{}
+	var collect func([]*protogen.Message)
+	collect = func(ms []*protogen.Message) {
+		for _, m := range ms {
+			if !m.Desc.IsMapEntry() {
+				messages = append(messages, m)
+			}
+			collect(m.Messages)
+		}
+	}
+	collect(f.Messages)
+	if len(messageMatchers) == 0 || len(messages) == 0 {
+		return nil
+	}
+
+	results := make([]projectRelations, len(typeMaps))
+	var wg sync.WaitGroup
+	wg.Add(len(typeMaps))
+	for id, types := range typeMaps {
+		id, scipTypes := id, types
+		go func() {
+			relations := make(map[string][]*scip.Relationship)
+			for _, t := range scipTypes {
+				for _, m := range messages {
+					relations, _ = matchProtoMessage(m, t, messageMatchers, symbols, relations)
+				}
+			}
+			results[id] = projectRelations{project: projects[id], relations: relations}
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func matchProtoMessage(m *protogen.Message, t *ScipType, matchers []MessageMatcher, symbols map[string]*scip.SymbolInformation, relations map[string][]*scip.Relationship) (map[string][]*scip.Relationship, bool) {
+	if t.Module || t.TypeSymbol == nil {
+		return relations, false
+	}
+	var best *MessageMatchResult
+	for _, matcher := range matchers {
+		if res := matcher.MatchMessage(m, t); res != nil && (best == nil || res.Score > best.Score) {
+			best = res
+		}
+	}
+	if best == nil {
+		return relations, false
+	}
+
+	siMap := map[*scip.SymbolInformation]string{t.TypeSymbol: string(m.Desc.FullName())}
+	for field, matches := range best.Fields {
+		for _, si := range matches {
+			siMap[si] = string(field.Desc.FullName())
+		}
+	}
+	relations = linkSymbols(siMap, symbols, relations)
+	glog.Infof("message %s matches: %s", m.Desc.FullName(), t.TypeSymbol.Symbol)
+
+	return relations, true
+}
+
+// relativeMessageName returns the name of m relative to its package, with the
+// names of the enclosing messages joined by sep, e.g. Outer_Inner for sep "_".
+func relativeMessageName(m *protogen.Message, sep string) string {
+	name := string(m.Desc.FullName())
+	if pkg := string(m.Desc.ParentFile().Package()); pkg != "" {
+		name = strings.TrimPrefix(name, pkg+".")
+	}
+	return strings.ReplaceAll(name, ".", sep)
+}
+
+func generateDefinition(f *protogen.File, desc protoreflect.Descriptor, loc protogen.Location, kind scip.SymbolInformation_Kind, doc []string, d *scip.Document) *scip.SymbolInformation {
+	symbol := makeDescriptorSymbol(f, desc)
+
+	symbolInfo := makeSymbolInformation(symbol, kind)
+	symbolInfo.Documentation = doc
+	occurence := makeDefinition(f, loc.Path, symbol)
+
+	d.Symbols = append(d.Symbols, symbolInfo)
+	d.Occurrences = append(d.Occurrences, occurence)
+
+	return symbolInfo
+}
+
+// protoPackage returns the package of the symbols declared in f. The files
+// without a package are given the empty package name ".".
+func protoPackage(f *protogen.File) *scip.Package {
+	name := f.Proto.GetPackage()
+	if name == "" {
+		name = "."
+	}
+	return &scip.Package{Manager: "proto", Name: name, Version: f.Desc.Syntax().String()}
+}
+
+// makeDescriptorSymbol returns the symbol of a message, enum, field, oneof or
+// enum value declared in f, e.g. proto/message/CommonMessage#my_string. for
+// the field my_string of CommonMessage. The enum values are nested in their
+// enum, e.g. proto/message/MyEnum#ENUM_VALUE_1.
+func makeDescriptorSymbol(f *protogen.File, desc protoreflect.Descriptor) string {
+	names := []*scip.Descriptor{}
+	for ; desc != nil && desc.Parent() != nil; desc = desc.Parent() {
+		suffix := scip.Descriptor_Term
+		switch desc.(type) {
+		case protoreflect.MessageDescriptor, protoreflect.EnumDescriptor:
+			suffix = scip.Descriptor_Type
+		}
+		names = append([]*scip.Descriptor{{Name: string(desc.Name()), Suffix: suffix}}, names...)
+	}
+
+	descriptors := []*scip.Descriptor{}
+	for _, namespace := range strings.Split(f.GeneratedFilenamePrefix, "/") {
+		descriptors = append(descriptors, &scip.Descriptor{Name: namespace, Suffix: scip.Descriptor_Namespace})
+	}
+	descriptors = append(descriptors, names...)
+	return scip.VerboseSymbolFormatter.FormatSymbol(&scip.Symbol{
+		Scheme:      "scip-proto",
+		Package:     protoPackage(f),
+		Descriptors: descriptors,
+	})
+}
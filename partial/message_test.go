@@ -0,0 +1,118 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestGenerateMessages(t *testing.T) {
+	fd := newTestFileDescriptor("Go_A")
+	fd.MessageType = append(fd.MessageType, &descriptorpb.DescriptorProto{
+		Name: proto.String("Outer"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{Name: proto.String("value"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), OneofIndex: proto.Int32(0)},
+		},
+		OneofDecl:  []*descriptorpb.OneofDescriptorProto{{Name: proto.String("choice")}},
+		NestedType: []*descriptorpb.DescriptorProto{{Name: proto.String("Inner")}},
+	})
+	fd.EnumType = []*descriptorpb.EnumDescriptorProto{{
+		Name:  proto.String("MyEnum"),
+		Value: []*descriptorpb.EnumValueDescriptorProto{{Name: proto.String("ENUM_VALUE_1"), Number: proto.Int32(0)}},
+	}}
+	f := newTestFileFromDescriptor(t, fd)
+
+	d := &scip.Document{}
+	symbols := generateMessages(f, d)
+	if symbols["protos.Outer.Inner"] == nil || symbols["protos.MyEnum.ENUM_VALUE_1"] != nil {
+		t.Errorf("expected the symbols by full name, got %v", symbols)
+	}
+
+	const prefix = "scip-proto proto protos proto3 proto/Go_A/"
+	want := map[string]scip.SymbolInformation_Kind{
+		prefix + "MyEnum#":              scip.SymbolInformation_Enum,
+		prefix + "MyEnum#ENUM_VALUE_1.": scip.SymbolInformation_EnumMember,
+		prefix + "Empty#":               scip.SymbolInformation_Message,
+		prefix + "Outer#":               scip.SymbolInformation_Message,
+		prefix + "Outer#value.":         scip.SymbolInformation_Field,
+		prefix + "Outer#choice.":        scip.SymbolInformation_Union,
+		prefix + "Outer#Inner#":         scip.SymbolInformation_Message,
+	}
+	if len(d.Symbols) != len(want) || len(d.Occurrences) != len(want) {
+		t.Errorf("expected %d symbols and occurrences, got %d and %d", len(want), len(d.Symbols), len(d.Occurrences))
+	}
+	for _, si := range d.Symbols {
+		if kind, ok := want[si.Symbol]; !ok || kind != si.Kind {
+			t.Errorf("unexpected symbol %s of kind %v", si.Symbol, si.Kind)
+		}
+	}
+	for _, o := range d.Occurrences {
+		if !scip.SymbolRole_Definition.Matches(o) {
+			t.Errorf("expected %s to be a definition", o.Symbol)
+		}
+	}
+}
+
+func TestMatchMessage(t *testing.T) {
+	fd := newTestFileDescriptor("Go_A")
+	fd.MessageType = append(fd.MessageType, &descriptorpb.DescriptorProto{
+		Name: proto.String("CommonMessage"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			{Name: proto.String("my_string"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), JsonName: proto.String("myString")},
+		},
+	})
+	f := newTestFileFromDescriptor(t, fd)
+	m := f.Messages[1]
+
+	tests := []struct {
+		matcher MessageMatcher
+		symbols []string
+		want    int
+	}{
+		{goGrpcMatcher{}, []string{goPackage + "CommonMessage#", goPackage + "CommonMessage#MyString.", goPackage + "CommonMessage#GetMyString()."}, 2},
+		{goGrpcMatcher{}, []string{"scip-go gomod Go_A cb6b82253d24 Go_A/other/CommonMessage#"}, -1},
+		{pythonGrpcMatcher{}, []string{pythonPackage + "`protos.Go_A_pb2`/CommonMessage#", pythonPackage + "`protos.Go_A_pb2`/CommonMessage#my_string.", pythonPackage + "`protos.Go_A_pb2`/CommonMessage#MY_STRING_FIELD_NUMBER."}, 2},
+		{pythonGrpcMatcher{}, []string{pythonPackage + "`protos.Go_A_pb2_grpc`/CommonMessage#"}, -1},
+		{tsGrpcMatcher{}, []string{tsPackage + "Ts_A/protos/`Go_A.ts`/CommonMessage#", tsPackage + "Ts_A/protos/`Go_A.ts`/CommonMessage#myString."}, 1},
+		{javaGrpcMatcher{}, []string{javaPackage + "protos/GoA#CommonMessage#", javaPackage + "protos/GoA#CommonMessage#getMyString()."}, 1},
+		{javaGrpcMatcher{}, []string{javaPackage + "protos/Other#CommonMessage#"}, -1},
+	}
+	for _, test := range tests {
+		ty := newTestType(t, test.symbols...)
+		res := test.matcher.MatchMessage(m, ty)
+		switch {
+		case test.want < 0 && res != nil:
+			t.Errorf("%T: expected %s not to match, got %v", test.matcher, ty.TypeSymbol.Symbol, res)
+		case test.want >= 0 && res == nil:
+			t.Errorf("%T: expected %s to match", test.matcher, ty.TypeSymbol.Symbol)
+		case test.want >= 0 && len(res.Fields[m.Fields[0]]) != test.want:
+			t.Errorf("%T: expected %d symbols for my_string, got %v", test.matcher, test.want, res.Fields[m.Fields[0]])
+		}
+	}
+}
+
+func TestLinkedMessageRelationships(t *testing.T) {
+	index := unmarshalIndex(t, linkTestdata(t, compileTestdata(t), testdataParams()))
+	const prefix = "scip-proto proto protos proto3 proto/message/CommonMessage#"
+	found := map[string]*scip.SymbolInformation{}
+	for _, d := range index.Documents {
+		for _, si := range d.Symbols {
+			found[si.Symbol] = si
+		}
+	}
+	for _, symbol := range []string{prefix, prefix + "inner_message."} {
+		si, ok := found[symbol]
+		if !ok {
+			t.Fatalf("no symbol %s", symbol)
+		}
+		if len(si.Relationships) == 0 {
+			t.Errorf("expected %s to list the linked symbols, got none", symbol)
+		}
+		if doc := strings.Join(si.Documentation, "\n"); !strings.Contains(doc, "Linked in ") {
+			t.Errorf("expected %s to document the linked projects, got %q", symbol, doc)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package partial
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"protoc-gen-scip/scip"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/golang/glog"
+)
+
+// Version is the version of the plugin, recorded in the metadata of the
+// generated index.
+const Version = "0.0.1"
+
+const toolName = "protoc-gen-scip"
+
+// ParseEncoding returns the text encoding named s, utf8 or utf16. An empty
+// name gives scip.TextEncoding_UnspecifiedTextEncoding, which keeps the
+// ranges as recorded by the indexers.
+func ParseEncoding(s string) (scip.TextEncoding, error) {
+	switch strings.ToLower(strings.ReplaceAll(s, "-", "")) {
+	case "":
+		return scip.TextEncoding_UnspecifiedTextEncoding, nil
+	case "utf8":
+		return scip.TextEncoding_UTF8, nil
+	case "utf16":
+		return scip.TextEncoding_UTF16, nil
+	}
+	return scip.TextEncoding_UnspecifiedTextEncoding, fmt.Errorf("unknown encoding %q, expected utf8 or utf16", s)
+}
+
+// mergeMetadata returns the metadata of the generated index. The tool is the
+// plugin, with the tools of the input indexes as its arguments, e.g.
+// scip-go@0.1. The encoding is the target one if given, or else the one
+// shared by the input indexes, see convertEncodings.
+func mergeMetadata(indexes []*scip.Index, sourceroot string, target scip.TextEncoding) *scip.Metadata {
+	metadata := &scip.Metadata{
+		ToolInfo:             &scip.ToolInfo{Name: toolName, Version: Version},
+		ProjectRoot:          appendPrefix(sourceroot),
+		TextDocumentEncoding: target,
+	}
+	seen := map[string]struct{}{}
+	for _, i := range indexes {
+		tool := i.GetMetadata().GetToolInfo()
+		if tool.GetName() == "" {
+			continue
+		}
+		arg := tool.GetName()
+		if tool.GetVersion() != "" {
+			arg += "@" + tool.GetVersion()
+		}
+		if _, ok := seen[arg]; !ok {
+			seen[arg] = struct{}{}
+			metadata.ToolInfo.Arguments = append(metadata.ToolInfo.Arguments, arg)
+		}
+	}
+	if target == scip.TextEncoding_UnspecifiedTextEncoding {
+		if encodings := indexEncodings(indexes); len(encodings) == 1 {
+			metadata.TextDocumentEncoding = encodings[0]
+		}
+	}
+	return metadata
+}
+
+// indexEncodings returns the distinct encodings given by the indexes.
+func indexEncodings(indexes []*scip.Index) []scip.TextEncoding {
+	encodings := []scip.TextEncoding{}
+	for _, i := range indexes {
+		e := i.GetMetadata().GetTextDocumentEncoding()
+		if e == scip.TextEncoding_UnspecifiedTextEncoding {
+			continue
+		}
+		found := false
+		for _, other := range encodings {
+			found = found || other == e
+		}
+		if !found {
+			encodings = append(encodings, e)
+		}
+	}
+	return encodings
+}
+
+// convertEncodings converts the ranges of the documents of the indexes to
+// the target encoding, reading the text of the documents from the source
+// root when the index does not hold it. Without a target, it only warns
+// about indexes using different encodings. The proto documents are left
+// as is, as their identifiers are ASCII.
+func convertEncodings(indexes []*scip.Index, sourceroot string, target scip.TextEncoding) {
+	if target == scip.TextEncoding_UnspecifiedTextEncoding {
+		if encodings := indexEncodings(indexes); len(encodings) > 1 {
+			glog.Warningf("the indexes use different text encodings %v, set encoding to convert their ranges", encodings)
+		}
+		return
+	}
+	for _, i := range indexes {
+		from := i.GetMetadata().GetTextDocumentEncoding()
+		if from == target {
+			continue
+		}
+		if from == scip.TextEncoding_UnspecifiedTextEncoding {
+			if len(i.Documents) > 0 {
+				glog.Warningf("no text encoding in the index of %s, assuming %v", i.GetMetadata().GetToolInfo().GetName(), target)
+			}
+			continue
+		}
+		for _, d := range i.Documents {
+			text := d.Text
+			if text == "" {
+				content, err := os.ReadFile(filepath.Join(sourceroot, d.RelativePath))
+				if err != nil {
+					glog.Warningf("can not convert the ranges of %s to %v: %v", d.RelativePath, target, err)
+					continue
+				}
+				text = string(content)
+			}
+			convertDocumentEncoding(d, strings.Split(text, "\n"), from)
+		}
+		i.Metadata.TextDocumentEncoding = target
+	}
+}
+
+// convertDocumentEncoding converts the characters of the ranges of the
+// document from one encoding to the other.
+func convertDocumentEncoding(d *scip.Document, lines []string, from scip.TextEncoding) {
+	convert := func(r []int32) {
+		if len(r) < 3 {
+			return
+		}
+		endLine := r[0]
+		if len(r) == 4 {
+			endLine = r[2]
+		}
+		r[1] = convertCharacter(lines, r[0], r[1], from)
+		r[len(r)-1] = convertCharacter(lines, endLine, r[len(r)-1], from)
+	}
+	for _, o := range d.Occurrences {
+		convert(o.Range)
+		convert(o.EnclosingRange)
+	}
+}
+
+// convertCharacter converts a character offset in a line, from UTF-8 bytes
+// to UTF-16 code units or the other way around. The offsets past the end of
+// the line are kept relative to it.
+func convertCharacter(lines []string, line int32, character int32, from scip.TextEncoding) int32 {
+	if line < 0 || int(line) >= len(lines) {
+		return character
+	}
+	text := lines[line]
+	offset, converted := int32(0), int32(0)
+	for len(text) > 0 {
+		if offset >= character {
+			return converted
+		}
+		r, utf8Len := utf8.DecodeRuneInString(text)
+		text = text[utf8Len:]
+		utf16Len := int32(1)
+		if r > 0xffff {
+			utf16Len = 2
+		}
+		if from == scip.TextEncoding_UTF8 {
+			offset, converted = offset+int32(utf8Len), converted+utf16Len
+		} else {
+			offset, converted = offset+utf16Len, converted+int32(utf8Len)
+		}
+	}
+	return converted + character - offset
+}
@@ -0,0 +1,75 @@
+package partial
+
+import (
+	"os"
+	"path/filepath"
+	"protoc-gen-scip/scip"
+	"reflect"
+	"testing"
+)
+
+func TestMergeMetadata(t *testing.T) {
+	newIndexes := func() []*scip.Index {
+		return []*scip.Index{
+			{Metadata: &scip.Metadata{ToolInfo: &scip.ToolInfo{Name: "scip-go", Version: "0.1"}, TextDocumentEncoding: scip.TextEncoding_UTF8}},
+			{Metadata: &scip.Metadata{ToolInfo: &scip.ToolInfo{Name: "scip-python", Version: "0.4.1"}, TextDocumentEncoding: scip.TextEncoding_UTF16}},
+			{Metadata: &scip.Metadata{ToolInfo: &scip.ToolInfo{Name: "scip-go", Version: "0.1"}, TextDocumentEncoding: scip.TextEncoding_UTF8}},
+			{},
+		}
+	}
+
+	m := mergeMetadata(newIndexes(), "/src", scip.TextEncoding_UnspecifiedTextEncoding)
+	if m.ToolInfo.Name != "protoc-gen-scip" || !reflect.DeepEqual(m.ToolInfo.Arguments, []string{"scip-go@0.1", "scip-python@0.4.1"}) {
+		t.Errorf("unexpected tool info %v", m.ToolInfo)
+	}
+	if m.ProjectRoot != "file:///src" {
+		t.Errorf("unexpected project root %s", m.ProjectRoot)
+	}
+	if m.TextDocumentEncoding != scip.TextEncoding_UnspecifiedTextEncoding {
+		t.Errorf("expected no encoding for conflicting indexes, got %v", m.TextDocumentEncoding)
+	}
+
+	m = mergeMetadata(newIndexes()[:1], "/src", scip.TextEncoding_UnspecifiedTextEncoding)
+	if m.TextDocumentEncoding != scip.TextEncoding_UTF8 {
+		t.Errorf("expected the encoding of the indexes, got %v", m.TextDocumentEncoding)
+	}
+}
+
+func TestConvertEncodings(t *testing.T) {
+	dir := t.TempDir()
+	// é is 2 bytes and 1 UTF-16 code unit, 😀 is 4 bytes and 2 code units.
+	if err := os.WriteFile(filepath.Join(dir, "client.py"), []byte("s = \"é😀\"; call()\nok()\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	index := &scip.Index{
+		Metadata: &scip.Metadata{TextDocumentEncoding: scip.TextEncoding_UTF16},
+		Documents: []*scip.Document{{
+			RelativePath: "client.py",
+			Occurrences: []*scip.Occurrence{
+				{Range: []int32{0, 11, 15}, EnclosingRange: []int32{0, 0, 1, 4}},
+				{Range: []int32{1, 0, 2}},
+			},
+		}},
+	}
+
+	convertEncodings([]*scip.Index{index}, dir, scip.TextEncoding_UTF8)
+	occurrences := index.Documents[0].Occurrences
+	if !reflect.DeepEqual(occurrences[0].Range, []int32{0, 14, 18}) {
+		t.Errorf("unexpected UTF-8 range %v", occurrences[0].Range)
+	}
+	if !reflect.DeepEqual(occurrences[0].EnclosingRange, []int32{0, 0, 1, 4}) || !reflect.DeepEqual(occurrences[1].Range, []int32{1, 0, 2}) {
+		t.Errorf("expected the ASCII ranges to be kept, got %v and %v", occurrences[0].EnclosingRange, occurrences[1].Range)
+	}
+	if index.Metadata.TextDocumentEncoding != scip.TextEncoding_UTF8 {
+		t.Errorf("expected the index to be UTF-8, got %v", index.Metadata.TextDocumentEncoding)
+	}
+
+	convertEncodings([]*scip.Index{index}, dir, scip.TextEncoding_UTF16)
+	if !reflect.DeepEqual(occurrences[0].Range, []int32{0, 11, 15}) {
+		t.Errorf("unexpected UTF-16 range %v", occurrences[0].Range)
+	}
+
+	if _, err := ParseEncoding("latin1"); err == nil {
+		t.Errorf("expected an error for an unknown encoding")
+	}
+}
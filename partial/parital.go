@@ -1,8 +1,7 @@
 package partial
 
 import (
-	"io"
-	"os"
+	"fmt"
 	"path/filepath"
 	"protoc-gen-scip/scip"
 	"strings"
@@ -17,22 +16,28 @@ import (
 // type symbolStringMap map[string]string
 
 var indexes []*scip.Index
-var typeMaps []map[string]*scipType
+var typeMaps []map[string]*ScipType
 var whiteListedSymbols sync.Map
 
 // var grpcImpls sync.Map
 
 // var globalSymbols symbolStringMap
 
-type scipType struct {
+// ScipType groups the symbols of a type found in a SCIP index, i.e. the type
+// itself and its methods or fields.
+type ScipType struct {
 	Name          string
 	TypeSymbol    *scip.SymbolInformation
 	Methods       []string
 	MethodSymbols []*scip.SymbolInformation
+	// Module is set for the pseudo type grouping the top level members of a
+	// namespace, e.g. functions and variables. Its TypeSymbol is the symbol of
+	// the namespace or module itself.
+	Module bool
 }
 
-func newScipType(name string, typeSymbol *scip.SymbolInformation, methods []string, methodSymbols []*scip.SymbolInformation) *scipType {
-	return &scipType{
+func newScipType(name string, typeSymbol *scip.SymbolInformation, methods []string, methodSymbols []*scip.SymbolInformation) *ScipType {
+	return &ScipType{
 		Name:          name,
 		TypeSymbol:    typeSymbol,
 		Methods:       methods,
@@ -40,7 +45,7 @@ func newScipType(name string, typeSymbol *scip.SymbolInformation, methods []stri
 	}
 }
 
-func (t *scipType) findMethods(s string) []*scip.SymbolInformation {
+func (t *ScipType) findMethods(s string) []*scip.SymbolInformation {
 	res := []*scip.SymbolInformation{}
 	for idx, m := range t.Methods {
 		if matchMethodName(m, s) {
@@ -50,13 +55,45 @@ func (t *scipType) findMethods(s string) []*scip.SymbolInformation {
 	return res
 }
 
-func (t *scipType) getTypeName() string {
+// findMembers returns the methods and fields of the type named exactly name.
+func (t *ScipType) findMembers(name string) []*scip.SymbolInformation {
+	res := []*scip.SymbolInformation{}
+	for idx, m := range t.Methods {
+		// parameters are recorded under the name of their method, but unlike
+		// methods and fields their symbol does not end with a dot.
+		if m == name && strings.HasSuffix(t.MethodSymbols[idx].Symbol, ".") {
+			res = append(res, t.MethodSymbols[idx])
+		}
+	}
+	return res
+}
+
+// TypeName returns the name of the type without its namespaces.
+func (t *ScipType) TypeName() string {
 	if split := strings.SplitAfter(t.Name, "/"); len(split) > 1 {
 		return split[len(split)-1]
 	}
 	return t.Name
 }
 
+// ShortName returns the name of the innermost type, e.g. Inner for the
+// nested type Outer::Inner.
+func (t *ScipType) ShortName() string {
+	name := t.TypeName()
+	if idx := strings.LastIndex(name, "::"); idx >= 0 {
+		return name[idx+2:]
+	}
+	return name
+}
+
+// Scope returns the namespaces of the type, without the trailing slash.
+func (t *ScipType) Scope() string {
+	if idx := strings.LastIndex(t.Name, "/"); idx >= 0 {
+		return t.Name[:idx]
+	}
+	return ""
+}
+
 func getServiceKey(s *protogen.Service) string {
 	return s.GoName
 }
@@ -77,31 +114,60 @@ func matchName(s string, frag string) bool {
 	return strings.Contains(getKeyName(s), getKeyName(frag))
 }
 
-func matchProtoService(s *protogen.Service, t *scipType, symbols map[string]*scip.SymbolInformation, relations map[string][]*scip.Relationship) (map[string][]*scip.Relationship, bool) {
+func matchProtoService(f *protogen.File, s *protogen.Service, t *ScipType, matchers []Matcher, scoped bool, symbols map[string]*scip.SymbolInformation, relations map[string][]*scip.Relationship) (map[string][]*scip.Relationship, bool) {
 	if t.TypeSymbol == nil {
 		glog.Infof("ill formed scip type: %v", *t)
 		return relations, false
 	}
-
-	if !matchName(t.getTypeName(), s.GoName) {
+	if mapping.excludes(s, t.TypeSymbol) {
 		return relations, false
 	}
-	if strings.HasSuffix(t.TypeSymbol.Symbol, "Go_B/cmd/server#") {
-		glog.Errorf("i  a m here and is %s\n", t.TypeSymbol.Symbol)
+
+	var best *MatchResult
+	var bestMatcher Matcher
+	for _, matcher := range matchers {
+		if scoped && !inScope(matcher, f, t) {
+			continue
+		}
+		if res := matcher.Match(s, t); res != nil && (best == nil || res.Score > best.Score) {
+			best, bestMatcher = res, matcher
+		}
+	}
+	if best == nil {
+		return relations, false
 	}
 
 	siMap := map[*scip.SymbolInformation]string{}
-	siMap[t.TypeSymbol] = getServiceKey(s)
-	for _, m := range s.Methods {
-		if matches := t.findMethods(m.GoName); len(matches) > 0 {
-			for _, si := range matches {
-				siMap[si] = getMethodKey(m)
-			}
-		} else {
-			return relations, false
+	if len(best.Service) == 0 && len(best.Streams) == 0 && len(best.Routes) == 0 {
+		siMap[t.TypeSymbol] = getServiceKey(s)
+	}
+	for _, si := range best.Service {
+		siMap[si] = getServiceKey(s)
+	}
+	for m, matches := range best.Methods {
+		for _, si := range matches {
+			siMap[si] = getMethodKey(m)
 		}
 	}
+	addStreamSymbols(best.Streams, siMap)
+	addRouteSymbols(best.Routes, siMap)
+
+	filterMapping(s, siMap)
+	if len(siMap) == 0 {
+		return relations, false
+	}
+
+	relations = linkSymbols(siMap, symbols, relations)
+	addClientSymbols(best, siMap, symbols)
+	markGeneratedSymbols(bestMatcher, f, siMap, symbols)
+	glog.Infof("service %s matches: %s", s.GoName, t.TypeSymbol.Symbol)
+
+	return relations, true
+}
 
+// linkSymbols adds the relationships between the symbols of siMap and the proto
+// symbols of their keys.
+func linkSymbols(siMap map[*scip.SymbolInformation]string, symbols map[string]*scip.SymbolInformation, relations map[string][]*scip.Relationship) map[string][]*scip.Relationship {
 	for si, key := range siMap {
 		whiteListedSymbols.Store(si.Symbol, struct{}{})
 		si.Relationships = append(si.Relationships, &scip.Relationship{
@@ -117,22 +183,7 @@ func matchProtoService(s *protogen.Service, t *scipType, symbols map[string]*sci
 			IsReference: true,
 		})
 	}
-	glog.Infof("service %s matches: %s", s.GoName, t.TypeSymbol.Symbol)
-
-	return relations, true
-}
-
-func addNamespacePrefixToSymbol(s string, prefix string) string {
-	if prefix == "" {
-		return s
-	}
-	sym, err := scip.ParseSymbol(s)
-	if err != nil {
-		glog.Errorf("can not parse symbol when altering the symbol uri for %v", s)
-		return s
-	}
-	sym.Descriptors = append([]*scip.Descriptor{{Name: prefix, Suffix: scip.Descriptor_Namespace}}, sym.Descriptors...)
-	return scip.VerboseSymbolFormatter.FormatSymbol(sym)
+	return relations
 }
 
 func addScipTypeFromSymbolInformation(mapId int, i *scip.SymbolInformation, desPrefix string) {
@@ -154,9 +205,13 @@ func addScipTypeFromSymbolInformation(mapId int, i *scip.SymbolInformation, desP
 		glog.Errorf("can not parse the symbol %v", i)
 		return
 	}
-	i.Symbol = addNamespacePrefixToSymbol(i.Symbol, desPrefix)
+	symbolInfos[mapId][i.Symbol] = i
+	original := i.Symbol
+	i.Symbol = rewriteSymbol(i.Symbol, desPrefix)
+	symbolInfos[mapId][i.Symbol] = i
+	originalSymbols.Store(i.Symbol, original)
 	for _, rel := range i.Relationships {
-		rel.Symbol = addNamespacePrefixToSymbol(rel.Symbol, desPrefix)
+		rel.Symbol = rewriteSymbol(rel.Symbol, desPrefix)
 	}
 
 	for _, desc := range sym.Descriptors {
@@ -172,6 +227,9 @@ func addScipTypeFromSymbolInformation(mapId int, i *scip.SymbolInformation, desP
 			disambiguator = desc.Disambiguator
 		} else if desc.Suffix == scip.Descriptor_Term {
 			methodName = desc.Name
+		} else if desc.Suffix == scip.Descriptor_Meta && typeName == "" && desc.Name != "__init__" {
+			// e.g. the properties of a top level object literal in scip-typescript
+			methodName = desc.Name
 		}
 	}
 
@@ -188,9 +246,29 @@ func addScipTypeFromSymbolInformation(mapId int, i *scip.SymbolInformation, desP
 		} else {
 			typeMaps[mapId][getKeyName(scopes, typeName)] = newScipType(getKeyName(scopes, typeName), i, []string{}, []*scip.SymbolInformation{})
 		}
+	} else if scopes != "" {
+		t, ok := typeMaps[mapId][scopes]
+		if !ok {
+			t = newScipType(scopes, nil, []string{}, []*scip.SymbolInformation{})
+			t.Module = true
+			typeMaps[mapId][scopes] = t
+		}
+		if methodName != "" {
+			t.Methods = append(t.Methods, getMethodName(methodName, disambiguator))
+			t.MethodSymbols = append(t.MethodSymbols, i)
+		} else if isModuleSymbol(sym) {
+			t.TypeSymbol = i
+		}
 	}
 }
 
+// isModuleSymbol reports whether the symbol stands for a namespace, or for a
+// module as scip-python does with the __init__: descriptor.
+func isModuleSymbol(sym *scip.Symbol) bool {
+	last := sym.Descriptors[len(sym.Descriptors)-1]
+	return last.Suffix == scip.Descriptor_Namespace || (last.Suffix == scip.Descriptor_Meta && last.Name == "__init__")
+}
+
 func filter(d *scip.Document) bool {
 	return true
 }
@@ -202,11 +280,24 @@ func makeOccurence(pos protoreflect.SourceLocation, symbol string) *scip.Occurre
 	}
 }
 
+// makeDefinition returns the definition occurrence of the descriptor at path,
+// whose range is the one of its name if it is known.
+func makeDefinition(f *protogen.File, path protoreflect.SourcePath, symbol string) *scip.Occurrence {
+	pos := f.Desc.SourceLocations().ByPath(childPath(path, descriptorNameField))
+	if len(pos.Path) == 0 {
+		pos = f.Desc.SourceLocations().ByPath(path)
+	}
+	occurence := makeOccurence(pos, symbol)
+	occurence.SymbolRoles = int32(scip.SymbolRole_Definition)
+	return occurence
+}
+
 func generateMethod(f *protogen.File, m *protogen.Method, d *scip.Document) *scip.SymbolInformation {
 	symbol := makeMethodSymbol(f, m)
 
-	symbolInfo := makeSymbolInformation(symbol, scip.SymbolInformation_UnspecifiedKind)
-	occurence := makeOccurence(f.Desc.SourceLocations().ByPath(m.Location.Path), symbol)
+	symbolInfo := makeSymbolInformation(symbol, scip.SymbolInformation_Method)
+	symbolInfo.Documentation = append(documentation(methodSignature(m), m.Comments), httpRulesDocumentation(m)...)
+	occurence := makeDefinition(f, m.Location.Path, symbol)
 
 	d.Symbols = append(d.Symbols, symbolInfo)
 	d.Occurrences = append(d.Occurrences, occurence)
@@ -218,8 +309,9 @@ func generateService(f *protogen.File, s *protogen.Service, d *scip.Document) ma
 	siMap := map[string]*scip.SymbolInformation{}
 	symbol := makeServiceSymbol(f, s)
 
-	symbolInfo := makeSymbolInformation(symbol, scip.SymbolInformation_UnspecifiedKind)
-	occurence := makeOccurence(f.Desc.SourceLocations().ByPath(s.Location.Path), symbol)
+	symbolInfo := makeSymbolInformation(symbol, scip.SymbolInformation_Interface)
+	symbolInfo.Documentation = documentation(serviceSignature(s), s.Comments)
+	occurence := makeDefinition(f, s.Location.Path, symbol)
 
 	d.Symbols = append(d.Symbols, symbolInfo)
 	d.Occurrences = append(d.Occurrences, occurence)
@@ -227,14 +319,49 @@ func generateService(f *protogen.File, s *protogen.Service, d *scip.Document) ma
 
 	for _, m := range s.Methods {
 		siMap[getMethodKey(m)] = generateMethod(f, m, d)
+		generateStreams(f, m, d, siMap)
+		generateRoutes(f, m, d, siMap)
 	}
 
 	return siMap
 }
 
-func generateProtoDocument(f *protogen.File, sourceroot string) *scip.Document {
+// linkService matches the service against the types of every index and
+// returns the relations found in each of them.
+func linkService(f *protogen.File, s *protogen.Service, matchers []Matcher, scoped bool, siMap map[string]*scip.SymbolInformation) []projectRelations {
+	numGoroutines := len(typeMaps)
+	relationMapChan := make(chan projectRelations, numGoroutines)
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+
+	for id, types := range typeMaps {
+		id, scipTypes := id, types
+		go func() {
+			relations := make(map[string][]*scip.Relationship)
+			for _, t := range scipTypes {
+				relations, _ = matchProtoService(f, s, t, matchers, scoped, siMap, relations)
+			}
+			if len(relations) > 0 {
+				relationMapChan <- projectRelations{project: projects[id], relations: relations}
+			}
+			wg.Done()
+		}()
+	}
+
+	wg.Wait()
+	close(relationMapChan)
+
+	results := []projectRelations{}
+	for r := range relationMapChan {
+		results = append(results, r)
+	}
+	return results
+}
+
+func generateProtoDocument(f *protogen.File, opts *Options) *scip.Document {
 	protoDoc := &scip.Document{}
-	absFilePath, err := filepath.Abs(*f.Proto.Name)
+	sourceroot := opts.SourceRoot
+	absFilePath, err := filepath.Abs(filepath.Join(opts.ProtoRoot, *f.Proto.Name))
 	if err != nil {
 		glog.Errorf("can not get the absolute path of the input proto: %v", err)
 		glog.Errorf("the filename is: %s", *f.Proto.Name)
@@ -254,43 +381,34 @@ func generateProtoDocument(f *protogen.File, sourceroot string) *scip.Document {
 		protoDoc.RelativePath = *f.Proto.Name
 	}
 
+	generateFileSymbol(f, protoDoc)
+	messageSymbols := generateMessages(f, protoDoc)
+	generateReferences(f, protoDoc)
+	mergeRelations(messageSymbols, linkMessages(f, opts.Matchers, messageSymbols))
+
 	for _, s := range f.Services {
 		siMap := generateService(f, s, protoDoc)
-		numGoroutines := len(typeMaps)
-		relationMapChan := make(chan map[string][]*scip.Relationship, numGoroutines)
-		var wg sync.WaitGroup
-		wg.Add(numGoroutines)
-
-		for _, types := range typeMaps {
-			scipTypes := types
-			go func() {
-				relations := make(map[string][]*scip.Relationship)
-				for _, t := range scipTypes {
-					relations, _ = matchProtoService(s, t, siMap, relations)
-				}
-				if len(relations) > 0 {
-					relationMapChan <- relations
-				}
-				wg.Done()
-			}()
+		results := linkService(f, s, opts.Matchers, true, siMap)
+		if len(results) == 0 && opts.Unscoped {
+			glog.Infof("no match for %s in its package, falling back to unscoped matching", s.GoName)
+			results = linkService(f, s, opts.Matchers, false, siMap)
+		}
+		if len(results) == 0 && opts.Fuzzy {
+			glog.Infof("no exact match for %s, falling back to fuzzy matching", s.GoName)
+			results = linkService(f, s, []Matcher{goGrpcFuzzyMatcher{}}, !opts.Unscoped, siMap)
 		}
 
-		wg.Wait()
-
-		close(relationMapChan)
+		if relations, ok := applyMapping(s, siMap); ok {
+			results = append(results, projectRelations{relations: relations})
+		}
 
-		if len(relationMapChan) == 0 {
+		if len(results) == 0 {
 			glog.Errorf("proto service implementation not found for %s", s.GoName)
 			glog.Errorf("skip the service: %s", s.GoName)
 			continue
 		}
 
-		// for m := range relationMapChan {
-		// 	// for key, rels := range m {
-		// 	// 	// siMap[key].Relationships = append(siMap[key].Relationships, rels...)
-		// 	// }
-		// }
-
+		mergeRelations(siMap, results)
 	}
 
 	return protoDoc
@@ -311,12 +429,8 @@ func makeMethodSymbol(f *protogen.File, method *protogen.Method) string {
 	descriptors = append(descriptors, &scip.Descriptor{Name: method.Parent.GoName, Suffix: scip.Descriptor_Type})
 	descriptors = append(descriptors, &scip.Descriptor{Name: method.GoName, Suffix: scip.Descriptor_Term})
 	return scip.VerboseSymbolFormatter.FormatSymbol(&scip.Symbol{
-		Scheme: "scip-proto",
-		Package: &scip.Package{
-			Manager: "proto",
-			Name:    *f.Proto.Package,
-			Version: *f.Proto.Syntax,
-		},
+		Scheme:      "scip-proto",
+		Package:     protoPackage(f),
 		Descriptors: descriptors,
 	})
 }
@@ -328,12 +442,8 @@ func makeServiceSymbol(f *protogen.File, service *protogen.Service) string {
 	}
 	descriptors = append(descriptors, &scip.Descriptor{Name: service.GoName, Suffix: scip.Descriptor_Type})
 	return scip.VerboseSymbolFormatter.FormatSymbol(&scip.Symbol{
-		Scheme: "scip-proto",
-		Package: &scip.Package{
-			Manager: "proto",
-			Name:    *f.Proto.Package,
-			Version: *f.Proto.Syntax,
-		},
+		Scheme:      "scip-proto",
+		Package:     protoPackage(f),
 		Descriptors: descriptors,
 	})
 }
@@ -353,33 +463,44 @@ func appendPrefix(path string) string {
 	return path
 }
 
-func indexScipFile(id int, scipFilePath string, sourceroot string, wg *sync.WaitGroup) {
+func indexScipFile(id int, scipFilePath string, sourceroot string, project *ManifestProject, wg *sync.WaitGroup) {
 	defer wg.Done()
+	// namespace returns the namespace prefixed to the symbols of the index.
+	namespace := func() string {
+		diff, ok := project.namespace()
+		if !ok {
+			var err error
+			if diff, err = filepath.Rel(sourceroot, project.root(indexes[id].Metadata.GetProjectRoot())); err != nil {
+				glog.Errorf("can not get the diff path for %s: %v, give its namespace in a manifest", scipFilePath, err)
+				diff = ""
+			}
+			diff = filepath.Clean(diff)
+		}
+		if diff == "." {
+			diff = ""
+		}
+		return diff
+	}
+
 	visitDocument := func(d *scip.Document) {
-		absDocPath := filepath.Join(removePrefix(indexes[id].Metadata.GetProjectRoot()), d.RelativePath)
+		projectRoot := project.root(indexes[id].Metadata.GetProjectRoot())
+		absDocPath := filepath.Join(projectRoot, d.RelativePath)
 		absDocPath = filepath.Clean(absDocPath)
 		newRelPath, err := filepath.Rel(sourceroot, absDocPath)
 		if err != nil {
 			glog.Errorf("can not get the new relative path for %s: %v", scipFilePath, err)
 			newRelPath = d.RelativePath
 		}
-		diff, err := filepath.Rel(sourceroot, removePrefix(indexes[id].Metadata.GetProjectRoot()))
-		if err != nil {
-			glog.Fatalf("can not get the diff path for %s: %v", newRelPath, err)
-			// newRelPath = d.RelativePath
-		}
-		diff = filepath.Clean(diff)
-		if diff == "." {
-			diff = ""
-		}
+		diff := namespace()
 		d.RelativePath = newRelPath
 		indexes[id].Documents = append(indexes[id].Documents, d)
 		if filter(d) {
 			for _, i := range d.Symbols {
+				addIndexPackage(id, i.Symbol)
 				addScipTypeFromSymbolInformation(id, i, diff)
 			}
 			for _, o := range d.Occurrences {
-				o.Symbol = addNamespacePrefixToSymbol(o.Symbol, diff)
+				o.Symbol = rewriteSymbol(o.Symbol, diff)
 				// sym, err := scip.ParseSymbol(o.Symbol)
 				// if err != nil {
 				// 	glog.Errorf("can not parse symbol name in occurrence: %v", o)
@@ -396,7 +517,12 @@ func indexScipFile(id int, scipFilePath string, sourceroot string, wg *sync.Wait
 	}
 
 	visitExternalSymbol := func(e *scip.SymbolInformation) {
-		// indexes[id].ExternalSymbols = append(indexes[id].ExternalSymbols, e)
+		diff := namespace()
+		e.Symbol = rewriteSymbol(e.Symbol, diff)
+		for _, rel := range e.Relationships {
+			rel.Symbol = rewriteSymbol(rel.Symbol, diff)
+		}
+		indexes[id].ExternalSymbols = append(indexes[id].ExternalSymbols, e)
 	}
 
 	visitor := scip.IndexVisitor{
@@ -405,7 +531,7 @@ func indexScipFile(id int, scipFilePath string, sourceroot string, wg *sync.Wait
 		VisitExternalSymbol: visitExternalSymbol,
 	}
 
-	scipFile, err := os.Open(scipFilePath)
+	scipFile, err := openScipFile(scipFilePath)
 	if err != nil {
 		glog.Errorf("Error opening file: %s\n", err.Error())
 		glog.Errorf("skip that file: %s", scipFilePath)
@@ -413,9 +539,8 @@ func indexScipFile(id int, scipFilePath string, sourceroot string, wg *sync.Wait
 		return
 	}
 	defer scipFile.Close()
-	is := io.Reader(scipFile)
 
-	err = visitor.ParseStreaming(is)
+	err = visitor.ParseStreaming(scipFile)
 	if err != nil {
 		glog.Errorf("error in visiting the scip file: %v", err)
 		glog.Errorf("skip that file: %s", scipFilePath)
@@ -428,6 +553,8 @@ func indexScipFile(id int, scipFilePath string, sourceroot string, wg *sync.Wait
 		indexes[id].Metadata = &scip.Metadata{}
 	}
 
+	projects[id] = project.name(project.root(indexes[id].Metadata.GetProjectRoot()))
+	namespaces[id] = namespace()
 	indexes[id].Metadata.ProjectRoot = appendPrefix(sourceroot)
 }
 
@@ -461,11 +588,23 @@ func hasOneOfRelationships(s *scip.SymbolInformation, rels map[string]struct{})
 	return false
 }
 
-func mergeIndexes(indexes []*scip.Index, newIndex *scip.Index) *scip.Index {
+// mergeIndexes adds the documents of the indexes to newIndex. In partial
+// mode, only the whitelisted symbols and the ones related to them are kept,
+// along with their occurrences.
+func mergeIndexes(indexes []*scip.Index, newIndex *scip.Index, mode Mode) *scip.Index {
 	if len(indexes) == 0 {
 		glog.Errorf("no index to be merged.")
 		return newIndex
 	}
+	if mode == ModeFull {
+		externalSymbols := []*scip.SymbolInformation{}
+		for _, i := range indexes {
+			newIndex.Documents = append(newIndex.Documents, i.Documents...)
+			externalSymbols = append(externalSymbols, i.ExternalSymbols...)
+		}
+		newIndex.ExternalSymbols = scip.FlattenSymbols(externalSymbols)
+		return newIndex
+	}
 
 	documents := make([][]*scip.SymbolInformation, len(indexes))
 	for id, i := range indexes {
@@ -495,7 +634,6 @@ func mergeIndexes(indexes []*scip.Index, newIndex *scip.Index) *scip.Index {
 		}
 	}
 
-	newIndex.Metadata = indexes[0].Metadata
 	for _, i := range indexes {
 		for _, d := range i.Documents {
 			newDoc := filterDocument(d, dependencies)
@@ -503,48 +641,150 @@ func mergeIndexes(indexes []*scip.Index, newIndex *scip.Index) *scip.Index {
 				newIndex.Documents = append(newIndex.Documents, newDoc)
 			}
 		}
-		// newIndex.ExternalSymbols = append(newIndex.ExternalSymbols, i.ExternalSymbols...)
 	}
 
 	return newIndex
 }
 
-func GenerateFile(gen *protogen.Plugin, files []*protogen.File, scipFilePaths []string, outputPath string, sourceroot string) {
-	indexes = make([]*scip.Index, len(scipFilePaths))
-	whiteListedSymbols = sync.Map{}
-	newIndex := &scip.Index{}
+// canonicalizeIndex merges the documents with the same path and sorts the
+// documents, symbols, occurrences and relationships, so that the generated
+// index does not depend on the order the indexes were read and linked in.
+func canonicalizeIndex(index *scip.Index) {
+	documents := scip.FlattenDocuments(index.Documents)
+	for _, d := range documents {
+		scip.CanonicalizeDocument(d)
+	}
+	index.Documents = scip.SortDocuments(documents)
+	index.ExternalSymbols = scip.CanonicalizeSymbols(index.ExternalSymbols)
+}
+
+// Mode selects what is kept of the input indexes.
+type Mode string
+
+const (
+	// ModePartial keeps the symbols linked to the proto files, the symbols
+	// related to them and their occurrences.
+	ModePartial Mode = "partial"
+	// ModeFull keeps every document, occurrence and external symbol, so that
+	// the generated index replaces the input ones.
+	ModeFull Mode = "full"
+)
+
+// ParseMode returns the Mode named s, ModePartial if s is empty.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case "", ModePartial:
+		return ModePartial, nil
+	case ModeFull:
+		return ModeFull, nil
+	}
+	return "", fmt.Errorf("unknown mode %q, expected %s or %s", s, ModePartial, ModeFull)
+}
+
+// Options configures GenerateFile.
+type Options struct {
+	// ScipFiles are the SCIP indexes to be merged.
+	ScipFiles []string
+	// OutputPath is the name of the generated index.
+	OutputPath string
+	// SourceRoot is the absolute source root of the generated index.
+	SourceRoot string
+	// ProtoRoot is the directory the names of the proto files are relative
+	// to, the working directory if it is empty.
+	ProtoRoot string
+	// Matchers link the proto services to the types found in the indexes.
+	// The default matchers are used if it is empty.
+	Matchers []Matcher
+	// Fuzzy enables the legacy name matching for the services that none of
+	// the matchers could link.
+	Fuzzy bool
+	// Unscoped enables matching the types outside of the packages of the
+	// generated code for the services that could not be linked otherwise.
+	Unscoped bool
+	// Mapping declares the links the matchers can not find, it may be nil.
+	Mapping *Mapping
+	// Manifest describes the indexes, it may be nil. Its indexes are merged
+	// in addition to ScipFiles.
+	Manifest *Manifest
+	// Mode selects what is kept of the input indexes, ModePartial if it is
+	// empty.
+	Mode Mode
+	// Rewrite selects how the symbols of the indexes are made unique,
+	// RewriteNamespace if it is empty.
+	Rewrite Rewrite
+	// Encoding is the text encoding the ranges of the documents are
+	// converted to, they are kept as is if it is unspecified.
+	Encoding scip.TextEncoding
+}
+
+// initIndexes resets the state shared by the goroutines indexing n SCIP
+// files, whose symbols are rewritten with r, RewriteNamespace if it is empty.
+func initIndexes(n int, r Rewrite) {
+	indexes = make([]*scip.Index, n)
 	for i := range indexes {
 		indexes[i] = &scip.Index{}
 	}
-	typeMaps = make([]map[string]*scipType, len(scipFilePaths))
+	whiteListedSymbols = sync.Map{}
+	clientSymbols = sync.Map{}
+	originalSymbols = sync.Map{}
+	projects = make([]string, n)
+	namespaces = make([]string, n)
+	rewrite = r
+	if rewrite == "" {
+		rewrite = RewriteNamespace
+	}
+	indexPackages = make([]map[string]struct{}, n)
+	for i := range indexPackages {
+		indexPackages[i] = map[string]struct{}{}
+	}
+	typeMaps = make([]map[string]*ScipType, n)
+	symbolInfos = make([]map[string]*scip.SymbolInformation, n)
 	for i := range typeMaps {
-		typeMaps[i] = map[string]*scipType{}
+		typeMaps[i] = map[string]*ScipType{}
+		symbolInfos[i] = map[string]*scip.SymbolInformation{}
+	}
+}
+
+func GenerateFile(gen *protogen.Plugin, files []*protogen.File, opts Options) {
+	scipFilePaths := opts.Manifest.addIndexes(opts.ScipFiles)
+	if len(opts.Matchers) == 0 {
+		opts.Matchers, _ = LookupMatchers(nil)
 	}
+	initIndexes(len(scipFilePaths), opts.Rewrite)
+	mapping = opts.Mapping
+	filesByPath = gen.FilesByPath
+	newIndex := &scip.Index{}
 	// globalSymbols = symbolStringMap{}
 
 	numGoroutines := len(scipFilePaths)
 	var wg sync.WaitGroup
 	wg.Add(numGoroutines)
 	for id, path := range scipFilePaths {
-		go indexScipFile(id, path, sourceroot, &wg)
+		go indexScipFile(id, path, opts.SourceRoot, opts.Manifest.project(path), &wg)
 	}
 
 	wg.Wait()
+	if rewrite == RewriteNone {
+		warnPackageCollisions(scipFilePaths)
+	}
+	convertEncodings(indexes, opts.SourceRoot, opts.Encoding)
 	protoDocs := []*scip.Document{}
 	for _, f := range files {
-		protoDoc := generateProtoDocument(f, sourceroot)
+		protoDoc := generateProtoDocument(f, &opts)
 		protoDocs = append(protoDocs, protoDoc)
-		// newIndex.Documents = append([]*scip.Document{scip.CanonicalizeDocument(protoDoc)}, newIndex.Documents...)
 	}
 
-	newIndex = mergeIndexes(indexes, newIndex)
+	linkClientCalls(indexes)
+	newIndex = mergeIndexes(indexes, newIndex, opts.Mode)
+	newIndex.Metadata = mergeMetadata(indexes, opts.SourceRoot, opts.Encoding)
 	newIndex.Documents = append(protoDocs, newIndex.Documents...)
+	canonicalizeIndex(newIndex)
 
 	bytes, err := proto.Marshal(newIndex)
 	if err != nil {
 		glog.Fatalf("failed to generate protobuf of the newly updated index: %v", err)
 	}
 
-	g := gen.NewGeneratedFile(outputPath, "")
+	g := gen.NewGeneratedFile(opts.OutputPath, "")
 	g.Write(bytes)
 }
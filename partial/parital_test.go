@@ -0,0 +1,173 @@
+package partial
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"protoc-gen-scip/scip"
+	"sync"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func TestGenerateServiceDefinitions(t *testing.T) {
+	fd := newTestFileDescriptor("Go_A", "Go_A_1")
+	fd.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{
+		{Path: []int32{6, 0}, Span: []int32{6, 0, 8, 1}},
+		{Path: []int32{6, 0, 1}, Span: []int32{6, 8, 12}},
+		{Path: []int32{6, 0, 2, 0}, Span: []int32{7, 2, 54}},
+		{Path: []int32{6, 0, 2, 0, 1}, Span: []int32{7, 6, 12}},
+	}}
+	f := newTestFileFromDescriptor(t, fd)
+	s := f.Services[0]
+
+	d := &scip.Document{}
+	symbols := generateService(f, s, d)
+
+	if kind := symbols[getServiceKey(s)].Kind; kind != scip.SymbolInformation_Interface {
+		t.Errorf("expected the service to be an interface, got %v", kind)
+	}
+	if kind := symbols[getMethodKey(s.Methods[0])].Kind; kind != scip.SymbolInformation_Method {
+		t.Errorf("expected the rpc to be a method, got %v", kind)
+	}
+	want := [][]int32{{6, 8, 6, 12}, {7, 6, 7, 12}}
+	for i, o := range d.Occurrences {
+		if !scip.SymbolRole_Definition.Matches(o) {
+			t.Errorf("expected %s to be a definition", o.Symbol)
+		}
+		for j := range want[i] {
+			if o.Range[j] != want[i][j] {
+				t.Errorf("range of %s = %v, want %v", o.Symbol, o.Range, want[i])
+				break
+			}
+		}
+	}
+}
+
+func TestMergeIndexes(t *testing.T) {
+	const (
+		linked   = "scip-go gomod Go_A cb6b82253d24 Go_A/proto/Go_AServer#"
+		unlinked = "scip-go gomod Go_A cb6b82253d24 Go_A/cmd/main()."
+		external = "scip-go gomod github.com/golang/glog 23def4e6c14b glog/Errorf()."
+	)
+	newIndexes := func() []*scip.Index {
+		return []*scip.Index{
+			{
+				Metadata: &scip.Metadata{},
+				Documents: []*scip.Document{
+					{RelativePath: "proto/Go_A_grpc.pb.go", Symbols: []*scip.SymbolInformation{{Symbol: linked}}},
+					{RelativePath: "cmd/main.go", Symbols: []*scip.SymbolInformation{{Symbol: unlinked}}, Occurrences: []*scip.Occurrence{{Symbol: external}}},
+				},
+				ExternalSymbols: []*scip.SymbolInformation{{Symbol: external}},
+			},
+			{ExternalSymbols: []*scip.SymbolInformation{{Symbol: external, Documentation: []string{"Errorf logs."}}}},
+		}
+	}
+	whiteListedSymbols = sync.Map{}
+	whiteListedSymbols.Store(linked, struct{}{})
+
+	partial := mergeIndexes(newIndexes(), &scip.Index{}, ModePartial)
+	if len(partial.Documents) != 1 || len(partial.ExternalSymbols) != 0 {
+		t.Errorf("expected the linked document only, got %v", partial)
+	}
+
+	full := mergeIndexes(newIndexes(), &scip.Index{}, ModeFull)
+	if len(full.Documents) != 2 || len(full.Documents[1].Occurrences) != 1 {
+		t.Errorf("expected every document and occurrence, got %v", full.Documents)
+	}
+	if len(full.ExternalSymbols) != 1 || len(full.ExternalSymbols[0].Documentation) != 1 {
+		t.Errorf("expected the external symbols to be merged, got %v", full.ExternalSymbols)
+	}
+
+	if _, err := ParseMode("everything"); err == nil {
+		t.Errorf("expected an error for an unknown mode")
+	}
+}
+
+// testdataFiles are the proto files of scip/testdata, whose indexes are
+// linked by linkTestdata.
+var testdataFiles = []string{"protos/Go_A.proto", "protos/Python_A.proto", "protos/Ts_A.proto", "protos/message.proto"}
+
+func testdataParams() Params {
+	return Params{
+		ScipDirs:   []string{"../scip/testdata"},
+		Include:    []string{"Go_A.scip", "pyA.scip", "tsA.scip"},
+		OutFile:    "total.scip",
+		SourceRoot: "/home/nn/RPCoverBenchmark",
+	}
+}
+
+func compileTestdata(t *testing.T) *pluginpb.CodeGeneratorRequest {
+	req, err := CompileRequest(context.Background(), []string{"../scip/testdata"}, testdataFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+// linkTestdata runs the plugin on req with the indexes of scip/testdata and
+// returns the generated index.
+func linkTestdata(t *testing.T, req *pluginpb.CodeGeneratorRequest, params Params) []byte {
+	resp, err := Link(proto.Clone(req).(*pluginpb.CodeGeneratorRequest), params)
+	if err != nil || resp.Error != nil || len(resp.File) != 1 {
+		t.Fatalf("unexpected response %v, %v", resp.GetError(), err)
+	}
+	return []byte(resp.File[0].GetContent())
+}
+
+func unmarshalIndex(t *testing.T, b []byte) *scip.Index {
+	index := &scip.Index{}
+	if err := proto.Unmarshal(b, index); err != nil {
+		t.Fatal(err)
+	}
+	return index
+}
+
+func TestGenerateFileIsDeterministic(t *testing.T) {
+	req := compileTestdata(t)
+	want := linkTestdata(t, req, testdataParams())
+	for i := 1; i < 5; i++ {
+		if got := linkTestdata(t, req, testdataParams()); !bytes.Equal(got, want) {
+			t.Fatalf("run %d generated a different index", i)
+		}
+	}
+
+	index := unmarshalIndex(t, want)
+	if len(index.Documents) <= len(req.FileToGenerate) {
+		t.Fatalf("expected the documents of the indexes to be linked, got %d documents", len(index.Documents))
+	}
+	for i, d := range index.Documents {
+		if i > 0 && index.Documents[i-1].RelativePath >= d.RelativePath {
+			t.Errorf("expected the documents to be sorted, got %s after %s", d.RelativePath, index.Documents[i-1].RelativePath)
+		}
+		for j, si := range d.Symbols {
+			if j > 0 && d.Symbols[j-1].Symbol >= si.Symbol {
+				t.Errorf("expected the symbols of %s to be sorted and unique, got %s after %s", d.RelativePath, si.Symbol, d.Symbols[j-1].Symbol)
+			}
+		}
+	}
+}
+
+func TestGenerateFileProtoRoot(t *testing.T) {
+	root, err := filepath.Abs("../scip/testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := testdataParams()
+	params.SourceRoot = root
+	params.ProtoRoot = "../scip/testdata"
+	index := unmarshalIndex(t, linkTestdata(t, compileTestdata(t), params))
+
+	paths := map[string]struct{}{}
+	for _, d := range index.Documents {
+		paths[d.RelativePath] = struct{}{}
+	}
+	for _, name := range testdataFiles {
+		if _, ok := paths[name]; !ok {
+			t.Errorf("expected the document %s relative to the source root, got %v", name, paths)
+		}
+	}
+}
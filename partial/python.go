@@ -0,0 +1,106 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+func init() {
+	RegisterMatcher("python-grpc", pythonGrpcMatcher{})
+}
+
+// pythonGrpcModuleSuffix is the suffix of the modules generated by grpcio-tools.
+const pythonGrpcModuleSuffix = "_pb2_grpc"
+
+// pythonGrpcMatcher matches the code generated by grpcio-tools and indexed by
+// scip-python, that is
+//   - the <Service>Servicer, <Service>Stub and <Service> classes of the
+//     *_pb2_grpc modules,
+//   - the classes implementing the service by subclassing <Service>Servicer,
+//   - the add_<Service>Servicer_to_server function.
+//
+// The proto names are kept as is by grpcio-tools.
+type pythonGrpcMatcher struct{}
+
+func (pythonGrpcMatcher) Match(s *protogen.Service, t *ScipType) *MatchResult {
+	if !strings.HasPrefix(t.TypeSymbol.Symbol, "scip-python ") {
+		return nil
+	}
+	name := string(s.Desc.Name())
+
+	if t.Module {
+		if !isPythonGrpcModule(t.Scope()) {
+			return nil
+		}
+		register := t.findMembers("add_" + name + "Servicer_to_server")
+		if len(register) == 0 {
+			return nil
+		}
+		return &MatchResult{Service: register, Methods: map[*protogen.Method][]*scip.SymbolInformation{}, Score: 2}
+	}
+
+	switch short := t.ShortName(); {
+	case isPythonGrpcModule(t.Scope()) && (short == name+"Servicer" || short == name+"Stub" || short == name):
+	case implementsType(t.TypeSymbol, pythonGrpcType(name+"Servicer")):
+	default:
+		return nil
+	}
+
+	res := &MatchResult{
+		Methods: map[*protogen.Method][]*scip.SymbolInformation{},
+		Score:   2,
+		Client:  isPythonGrpcModule(t.Scope()) && (t.ShortName() == name+"Stub" || t.ShortName() == name),
+	}
+	for _, m := range s.Methods {
+		if matches := t.findMembers(string(m.Desc.Name())); len(matches) > 0 {
+			res.Methods[m] = matches
+		}
+	}
+	return res
+}
+
+// InScope matches the module generated for f, e.g. protos.Go_A_pb2_grpc for
+// protos/Go_A.proto. The module may live in a package.
+func (pythonGrpcMatcher) InScope(f *protogen.File, scope string) bool {
+	module := strings.ReplaceAll(strings.TrimSuffix(f.Desc.Path(), ".proto"), "/", ".") + pythonGrpcModuleSuffix
+	last := scope[strings.LastIndex(scope, "/")+1:]
+	return last == module || strings.HasSuffix(last, "."+module)
+}
+
+// MatchMessage matches the class generated by protoc for m in the *_pb2 module,
+// its fields and the constants holding their numbers.
+func (pythonGrpcMatcher) MatchMessage(m *protogen.Message, t *ScipType) *MessageMatchResult {
+	if !strings.HasPrefix(t.TypeSymbol.Symbol, "scip-python ") || t.TypeName() != "::"+relativeMessageName(m, "::") {
+		return nil
+	}
+	module := strings.ReplaceAll(strings.TrimSuffix(m.Desc.ParentFile().Path(), ".proto"), "/", ".") + "_pb2"
+	scope := t.Scope()
+	if last := scope[strings.LastIndex(scope, "/")+1:]; last != module && !strings.HasSuffix(last, "."+module) {
+		return nil
+	}
+
+	res := &MessageMatchResult{Fields: map[*protogen.Field][]*scip.SymbolInformation{}, Score: 2}
+	for _, field := range m.Fields {
+		name := string(field.Desc.Name())
+		if matches := append(t.findMembers(name), t.findMembers(strings.ToUpper(name)+"_FIELD_NUMBER")...); len(matches) > 0 {
+			res.Fields[field] = matches
+		}
+	}
+	return res
+}
+
+// isPythonGrpcModule reports whether the innermost namespace of scope is a
+// module generated by grpcio-tools.
+func isPythonGrpcModule(scope string) bool {
+	return strings.HasSuffix(scope[strings.LastIndex(scope, "/")+1:], pythonGrpcModuleSuffix)
+}
+
+// pythonGrpcType matches the class named name of a module generated by
+// grpcio-tools, for implementsType.
+func pythonGrpcType(name string) func(string, []*scip.Descriptor) bool {
+	return func(_ string, descriptors []*scip.Descriptor) bool {
+		return len(descriptors) >= 2 && descriptors[len(descriptors)-1].Name == name && strings.HasSuffix(descriptors[len(descriptors)-2].Name, pythonGrpcModuleSuffix)
+	}
+}
@@ -0,0 +1,96 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"testing"
+)
+
+const pythonPackage = "scip-python python Python_A f52a7209f4369c493521023dbc926dc20d7bbe99 "
+
+func TestPythonGrpcMatcher(t *testing.T) {
+	f := newTestFile(t, "Python_A", "Python_A_1", "Python_A_2")
+	s := f.Services[0]
+
+	tests := []struct {
+		name    string
+		symbols []string
+		methods int
+	}{
+		{
+			name: "servicer",
+			symbols: []string{
+				pythonPackage + "`protos.Python_A_pb2_grpc`/Python_AServicer#",
+				pythonPackage + "`protos.Python_A_pb2_grpc`/Python_AServicer#Python_A_1().",
+				pythonPackage + "`protos.Python_A_pb2_grpc`/Python_AServicer#Python_A_1().(request)",
+				pythonPackage + "`protos.Python_A_pb2_grpc`/Python_AServicer#Python_A_2().",
+			},
+			methods: 2,
+		},
+		{
+			name: "stub",
+			symbols: []string{
+				pythonPackage + "`protos.Python_A_pb2_grpc`/Python_AStub#",
+				pythonPackage + "`protos.Python_A_pb2_grpc`/Python_AStub#__init__().",
+				pythonPackage + "`protos.Python_A_pb2_grpc`/Python_AStub#Python_A_1.",
+				pythonPackage + "`protos.Python_A_pb2_grpc`/Python_AStub#Python_A_2.",
+			},
+			methods: 2,
+		},
+		{
+			name: "register function",
+			symbols: []string{
+				pythonPackage + "`protos.Python_A_pb2_grpc`/__init__:",
+				pythonPackage + "`protos.Python_A_pb2_grpc`/add_Python_AServicer_to_server().",
+			},
+			methods: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := pythonGrpcMatcher{}.Match(s, newTestType(t, tt.symbols...))
+			if res == nil {
+				t.Fatalf("expected a match")
+			}
+			if len(res.Methods) != tt.methods {
+				t.Errorf("expected %d matched methods, got %d", tt.methods, len(res.Methods))
+			}
+			for m, matches := range res.Methods {
+				if len(matches) != 1 {
+					t.Errorf("expected a single symbol for %s, got %v", m.GoName, matches)
+				}
+			}
+		})
+	}
+}
+
+func TestPythonGrpcMatcherSubclass(t *testing.T) {
+	f := newTestFile(t, "Python_A", "Python_A_1")
+	s := f.Services[0]
+
+	initIndexes(1, "")
+	addScipTypeFromSymbolInformation(0, &scip.SymbolInformation{
+		Symbol: pythonPackage + "server/Server#",
+		Relationships: []*scip.Relationship{{
+			Symbol:           pythonPackage + "`protos.Python_A_pb2_grpc`/Python_AServicer#",
+			IsImplementation: true,
+		}},
+	}, "Python_A")
+	addScipTypeFromSymbolInformation(0, &scip.SymbolInformation{Symbol: pythonPackage + "server/Server#Python_A_1()."}, "Python_A")
+	server := typeMaps[0]["server/::Server"]
+
+	res := pythonGrpcMatcher{}.Match(s, server)
+	if res == nil {
+		t.Fatalf("expected the subclass of Python_AServicer to match")
+	}
+	if len(res.Methods[s.Methods[0]]) != 1 {
+		t.Errorf("expected Python_A_1 to match, got %v", res.Methods)
+	}
+
+	unrelated := newTestType(t,
+		pythonPackage+"server/Python_AHelper#",
+		pythonPackage+"server/Python_AHelper#Python_A_1().",
+	)
+	if res := (pythonGrpcMatcher{}).Match(s, unrelated); res != nil {
+		t.Errorf("expected a class outside of the generated modules not to match, got %v", res)
+	}
+}
@@ -0,0 +1,128 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// filesByPath holds every file of the plugin request, including the imported
+// ones, to resolve the symbols of the types used in the proto files.
+var filesByPath map[string]*protogen.File
+
+// Field numbers in the descriptor.proto messages, used to build the source
+// paths of the type names and imports.
+const (
+	descriptorNameField   = 1 // the name of every *DescriptorProto
+	fileDependencyField   = 3 // FileDescriptorProto.dependency
+	methodInputTypeField  = 2 // MethodDescriptorProto.input_type
+	methodOutputTypeField = 3 // MethodDescriptorProto.output_type
+	fieldExtendeeField    = 2 // FieldDescriptorProto.extendee
+	fieldTypeNameField    = 6 // FieldDescriptorProto.type_name
+)
+
+// generateFileSymbol adds the symbol of the proto file itself, which the import
+// statements refer to.
+func generateFileSymbol(f *protogen.File, d *scip.Document) {
+	symbol := makeFileSymbol(f)
+	d.Symbols = append(d.Symbols, makeSymbolInformation(symbol, scip.SymbolInformation_File))
+	d.Occurrences = append(d.Occurrences, &scip.Occurrence{
+		Range:       []int32{0, 0, 0},
+		Symbol:      symbol,
+		SymbolRoles: int32(scip.SymbolRole_Definition),
+	})
+}
+
+// makeFileSymbol returns the symbol of a proto file, i.e. the namespaces of
+// the symbols it declares, e.g. proto/message/.
+func makeFileSymbol(f *protogen.File) string {
+	descriptors := []*scip.Descriptor{}
+	for _, namespace := range strings.Split(f.GeneratedFilenamePrefix, "/") {
+		descriptors = append(descriptors, &scip.Descriptor{Name: namespace, Suffix: scip.Descriptor_Namespace})
+	}
+	return scip.VerboseSymbolFormatter.FormatSymbol(&scip.Symbol{
+		Scheme:      "scip-proto",
+		Package:     protoPackage(f),
+		Descriptors: descriptors,
+	})
+}
+
+// generateReferences adds the reference occurrences of the imports and of the
+// message and enum types used by the fields and methods of f.
+func generateReferences(f *protogen.File, d *scip.Document) {
+	addReference := func(path protoreflect.SourcePath, symbol string) {
+		pos := f.Desc.SourceLocations().ByPath(path)
+		if len(pos.Path) == 0 || symbol == "" {
+			return
+		}
+		d.Occurrences = append(d.Occurrences, makeOccurence(pos, symbol))
+	}
+
+	imports := f.Desc.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if imported, ok := filesByPath[imports.Get(i).Path()]; ok {
+			addReference(protoreflect.SourcePath{fileDependencyField, int32(i)}, makeFileSymbol(imported))
+		}
+	}
+
+	var addFields func(fields []*protogen.Field)
+	addFields = func(fields []*protogen.Field) {
+		for _, field := range fields {
+			addReference(childPath(field.Location.Path, fieldTypeNameField), fieldTypeSymbol(field.Desc))
+			if field.Desc.IsExtension() {
+				addReference(childPath(field.Location.Path, fieldExtendeeField), descriptorSymbol(field.Desc.ContainingMessage()))
+			}
+		}
+	}
+	var addMessages func(messages []*protogen.Message)
+	addMessages = func(messages []*protogen.Message) {
+		for _, m := range messages {
+			if m.Desc.IsMapEntry() {
+				continue
+			}
+			addFields(m.Fields)
+			addFields(m.Extensions)
+			addMessages(m.Messages)
+		}
+	}
+	addMessages(f.Messages)
+	addFields(f.Extensions)
+
+	for _, s := range f.Services {
+		for _, m := range s.Methods {
+			addReference(childPath(m.Location.Path, methodInputTypeField), descriptorSymbol(m.Desc.Input()))
+			addReference(childPath(m.Location.Path, methodOutputTypeField), descriptorSymbol(m.Desc.Output()))
+		}
+	}
+}
+
+// fieldTypeSymbol returns the symbol of the message or enum type of a field,
+// the type of the values for a map field.
+func fieldTypeSymbol(field protoreflect.FieldDescriptor) string {
+	if field.IsMap() {
+		field = field.MapValue()
+	}
+	if field.Message() != nil {
+		return descriptorSymbol(field.Message())
+	}
+	if field.Enum() != nil {
+		return descriptorSymbol(field.Enum())
+	}
+	return ""
+}
+
+// descriptorSymbol returns the symbol of a descriptor of any file of the
+// request, or an empty string if its file is unknown.
+func descriptorSymbol(desc protoreflect.Descriptor) string {
+	f, ok := filesByPath[desc.ParentFile().Path()]
+	if !ok {
+		return ""
+	}
+	return makeDescriptorSymbol(f, desc)
+}
+
+func childPath(path protoreflect.SourcePath, field int32) protoreflect.SourcePath {
+	return append(append(protoreflect.SourcePath{}, path...), field)
+}
@@ -0,0 +1,61 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"testing"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func TestGenerateReferences(t *testing.T) {
+	message := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("protos/message.proto"),
+		Package:     proto.String("protos"),
+		Syntax:      proto.String("proto3"),
+		Options:     &descriptorpb.FileOptions{GoPackage: proto.String("./proto")},
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("CommonMessage")}},
+	}
+	service := newTestFileDescriptor("Go_A", "Go_A_1")
+	service.Dependency = []string{"protos/message.proto"}
+	service.Service[0].Method[0].InputType = proto.String(".protos.CommonMessage")
+	service.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{
+		{Path: []int32{3, 0}, Span: []int32{2, 0, 30}},
+		{Path: []int32{6, 0, 2, 0}, Span: []int32{5, 2, 40}},
+		{Path: []int32{6, 0, 2, 0, 2}, Span: []int32{5, 13, 26}},
+		{Path: []int32{6, 0, 2, 0, 3}, Span: []int32{5, 37, 42}},
+	}}
+
+	gen, err := protogen.Options{}.New(&pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{service.GetName()},
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{message, service},
+	})
+	if err != nil {
+		t.Fatalf("failed to create the plugin: %v", err)
+	}
+	filesByPath = gen.FilesByPath
+	defer func() { filesByPath = nil }()
+
+	d := &scip.Document{}
+	generateReferences(gen.FilesByPath[service.GetName()], d)
+
+	want := map[string][]int32{
+		"scip-proto proto protos proto3 proto/message/":               {2, 0, 2, 30},
+		"scip-proto proto protos proto3 proto/message/CommonMessage#": {5, 13, 5, 26},
+		"scip-proto proto protos proto3 proto/Go_A/Empty#":            {5, 37, 5, 42},
+	}
+	if len(d.Occurrences) != len(want) {
+		t.Fatalf("expected %d references, got %v", len(want), d.Occurrences)
+	}
+	for _, o := range d.Occurrences {
+		r, ok := want[o.Symbol]
+		if !ok || len(o.Range) != len(r) || o.Range[0] != r[0] || o.Range[1] != r[1] || o.Range[3] != r[3] {
+			t.Errorf("unexpected reference %v", o)
+		}
+		if scip.SymbolRole_Definition.Matches(o) {
+			t.Errorf("expected %s not to be a definition", o.Symbol)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package partial
+
+import (
+	"fmt"
+	"protoc-gen-scip/scip"
+	"sort"
+	"strings"
+)
+
+// projects holds the original project root of each index, without the file://
+// prefix.
+var projects []string
+
+// projectRelations holds the relations found in the index of a project, keyed
+// by proto symbol.
+type projectRelations struct {
+	project   string
+	relations map[string][]*scip.Relationship
+}
+
+// mergeRelations adds the relations to the proto symbols, so that they list
+// the symbols linked to them, and documents the project of these symbols.
+func mergeRelations(symbols map[string]*scip.SymbolInformation, results []projectRelations) {
+	implementers := map[string]map[string][]string{}
+	for _, r := range results {
+		for key, rels := range r.relations {
+			si, ok := symbols[key]
+			if !ok {
+				continue
+			}
+			si.Relationships = append(si.Relationships, rels...)
+			if r.project == "" {
+				continue
+			}
+			if _, ok := implementers[key]; !ok {
+				implementers[key] = map[string][]string{}
+			}
+			for _, rel := range rels {
+				implementers[key][r.project] = append(implementers[key][r.project], rel.Symbol)
+			}
+		}
+	}
+
+	for key, si := range symbols {
+		if len(si.Relationships) == 0 {
+			continue
+		}
+		si.Relationships = scip.FlattenRelationship(si.Relationships)
+		sort.Slice(si.Relationships, func(i, j int) bool {
+			return si.Relationships[i].Symbol < si.Relationships[j].Symbol
+		})
+		si.Documentation = append(si.Documentation, implementationsDocumentation(implementers[key])...)
+	}
+}
+
+// implementationsDocumentation lists the linked symbols of each project.
+func implementationsDocumentation(implementers map[string][]string) []string {
+	names := make([]string, 0, len(implementers))
+	for project := range implementers {
+		names = append(names, project)
+	}
+	sort.Strings(names)
+
+	doc := []string{}
+	for _, project := range names {
+		symbols := implementers[project]
+		sort.Strings(symbols)
+		var b strings.Builder
+		fmt.Fprintf(&b, "Linked in %s:", project)
+		for i, symbol := range symbols {
+			if i == 0 || symbol != symbols[i-1] {
+				fmt.Fprintf(&b, "\n- `%s`", symbol)
+			}
+		}
+		doc = append(doc, b.String())
+	}
+	return doc
+}
@@ -0,0 +1,48 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"strings"
+	"testing"
+)
+
+func TestMergeRelations(t *testing.T) {
+	const (
+		protoMethod = "scip-proto proto protos proto3 proto/Go_A/Go_A#Go_A_1."
+		goMethod    = "scip-go gomod Go_A cb6b82253d24 Go_A/proto/Go_AServer#Go_A_1."
+		pyMethod    = "scip-python python Py_A 0.1 `protos.Go_A_pb2_grpc`/Go_AServicer#Go_A_1()."
+	)
+	symbols := map[string]*scip.SymbolInformation{
+		"Go_A_1": {Symbol: protoMethod},
+		"Go_A_2": {Symbol: "scip-proto proto protos proto3 proto/Go_A/Go_A#Go_A_2."},
+	}
+	mergeRelations(symbols, []projectRelations{
+		{project: "/src/Py_A", relations: map[string][]*scip.Relationship{
+			"Go_A_1": {{Symbol: pyMethod, IsReference: true}},
+		}},
+		{project: "/src/Go_A", relations: map[string][]*scip.Relationship{
+			"Go_A_1": {{Symbol: goMethod, IsReference: true}, {Symbol: goMethod, IsReference: true}},
+		}},
+		{relations: map[string][]*scip.Relationship{
+			"Go_A_1":  {{Symbol: goMethod, IsReference: true}},
+			"Unknown": {{Symbol: goMethod, IsReference: true}},
+		}},
+	})
+
+	si := symbols["Go_A_1"]
+	if len(si.Relationships) != 2 || si.Relationships[0].Symbol != goMethod || si.Relationships[1].Symbol != pyMethod {
+		t.Fatalf("expected deduplicated, sorted relationships, got %v", si.Relationships)
+	}
+	if len(si.Documentation) != 2 {
+		t.Fatalf("expected the implementations of two projects to be documented, got %v", si.Documentation)
+	}
+	if !strings.HasPrefix(si.Documentation[0], "Linked in /src/Go_A:") || strings.Count(si.Documentation[0], goMethod) != 1 {
+		t.Errorf("unexpected documentation for /src/Go_A: %q", si.Documentation[0])
+	}
+	if !strings.HasPrefix(si.Documentation[1], "Linked in /src/Py_A:") {
+		t.Errorf("unexpected documentation for /src/Py_A: %q", si.Documentation[1])
+	}
+	if other := symbols["Go_A_2"]; len(other.Relationships) != 0 || len(other.Documentation) != 0 {
+		t.Errorf("expected Go_A_2 to be left untouched, got %v", other)
+	}
+}
@@ -0,0 +1,168 @@
+package partial
+
+import (
+	"fmt"
+	"protoc-gen-scip/scip"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/golang/glog"
+)
+
+// Rewrite selects how the symbols of the indexes are made unique when they
+// are merged, using the namespace of their index.
+type Rewrite string
+
+const (
+	// RewriteNamespace prefixes the descriptors of the symbols with the
+	// namespace, e.g. scip-go gomod Go_A v1 Go_A/proto/Server#.
+	RewriteNamespace Rewrite = "namespace"
+	// RewritePackage prefixes the package name of the symbols with the
+	// namespace, e.g. scip-go gomod Go_A/Go_A v1 proto/Server#.
+	RewritePackage Rewrite = "package"
+	// RewriteVersion prefixes the package version of the symbols with the
+	// namespace, e.g. scip-go gomod Go_A Go_A/v1 proto/Server#.
+	RewriteVersion Rewrite = "version"
+	// RewriteNone leaves the symbols untouched, for indexes whose packages
+	// already differ.
+	RewriteNone Rewrite = "none"
+)
+
+// ParseRewrite returns the Rewrite named s, RewriteNamespace if s is empty.
+func ParseRewrite(s string) (Rewrite, error) {
+	switch Rewrite(s) {
+	case "":
+		return RewriteNamespace, nil
+	case RewriteNamespace, RewritePackage, RewriteVersion, RewriteNone:
+		return Rewrite(s), nil
+	}
+	return "", fmt.Errorf("unknown rewrite %q, expected %s, %s, %s or %s", s, RewriteNamespace, RewritePackage, RewriteVersion, RewriteNone)
+}
+
+var rewrite = RewriteNamespace
+
+// namespaces holds the namespace of each index, the one its symbols are
+// rewritten with.
+var namespaces []string
+
+// originalSymbols maps the rewritten symbols defined by the indexes to their
+// original one.
+var originalSymbols sync.Map
+
+// indexPackages holds the packages of the symbols defined in each index, to
+// tell whether RewriteNone leaves colliding symbols.
+var indexPackages []map[string]struct{}
+
+// rewriteSymbol makes the symbol unique to the index of the given
+// namespace, according to rewrite. Local symbols, the ones that can not be
+// parsed and the ones of an empty namespace are left as is.
+func rewriteSymbol(s string, namespace string) string {
+	if namespace == "" || rewrite == RewriteNone || scip.IsLocalSymbol(s) {
+		return s
+	}
+	parts, ok := splitSymbol(s)
+	if !ok {
+		glog.Errorf("can not parse symbol when altering the symbol uri for %v", s)
+		return s
+	}
+	switch rewrite {
+	case RewritePackage:
+		parts[2] = prefixPackagePart(namespace, parts[2])
+	case RewriteVersion:
+		parts[3] = prefixPackagePart(namespace, parts[3])
+	default:
+		prefix := ""
+		for _, name := range strings.Split(namespace, "/") {
+			prefix += escapeDescriptorName(name) + "/"
+		}
+		parts[4] = prefix + parts[4]
+	}
+	return strings.Join(parts, " ")
+}
+
+// splitSymbol splits a global symbol in its scheme, package manager, name
+// and version, and descriptors. The parts are kept escaped, so that they can
+// be joined back with spaces.
+func splitSymbol(s string) ([]string, bool) {
+	parts := []string{}
+	start := 0
+	for i := 0; i < len(s) && len(parts) < 4; i++ {
+		if s[i] != ' ' {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == ' ' {
+			// an escaped space
+			i++
+			continue
+		}
+		parts = append(parts, s[start:i])
+		start = i + 1
+	}
+	if len(parts) < 4 {
+		return nil, false
+	}
+	return append(parts, s[start:]), true
+}
+
+// prefixPackagePart prefixes an escaped package name or version with the
+// namespace, the empty ones written . being replaced by it.
+func prefixPackagePart(namespace string, part string) string {
+	namespace = strings.ReplaceAll(namespace, " ", "  ")
+	if part == "." || part == "" {
+		return namespace
+	}
+	return namespace + "/" + part
+}
+
+func escapeDescriptorName(name string) string {
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-+$_", r) {
+			return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+		}
+	}
+	return name
+}
+
+// symbolPackage returns the escaped package manager, name and version of a
+// global symbol.
+func symbolPackage(s string) (string, bool) {
+	parts, ok := splitSymbol(s)
+	if !ok || scip.IsLocalSymbol(s) {
+		return "", false
+	}
+	return strings.Join(parts[1:4], " "), true
+}
+
+// addIndexPackage records the package of a symbol defined in an index.
+func addIndexPackage(id int, s string) {
+	if rewrite != RewriteNone {
+		return
+	}
+	if pkg, ok := symbolPackage(s); ok {
+		indexPackages[id][pkg] = struct{}{}
+	}
+}
+
+// warnPackageCollisions warns about the packages defined by several indexes,
+// whose symbols RewriteNone merges.
+func warnPackageCollisions(scipFilePaths []string) {
+	owners := map[string][]string{}
+	for id, packages := range indexPackages {
+		for pkg := range packages {
+			owners[pkg] = append(owners[pkg], scipFilePaths[id])
+		}
+	}
+	collisions := []string{}
+	for pkg, paths := range owners {
+		if len(paths) > 1 {
+			sort.Strings(paths)
+			collisions = append(collisions, fmt.Sprintf("%s (%s)", pkg, strings.Join(paths, ", ")))
+		}
+	}
+	sort.Strings(collisions)
+	for _, c := range collisions {
+		glog.Warningf("the package %s is defined by several indexes, their symbols are merged as rewrite is %s", c, RewriteNone)
+	}
+}
@@ -0,0 +1,45 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"testing"
+)
+
+func TestRewriteSymbol(t *testing.T) {
+	defer func() { rewrite = RewriteNamespace }()
+
+	goSymbol := "scip-go gomod Go_A v1 `Go_A/proto`/Go_AServer#"
+	tsSymbol := "scip-typescript npm . . protos/`Go_A.ts`/Go_AClient#"
+	tests := []struct {
+		rewrite   Rewrite
+		symbol    string
+		namespace string
+		want      string
+	}{
+		{RewriteNamespace, goSymbol, "Go_A", "scip-go gomod Go_A v1 Go_A/`Go_A/proto`/Go_AServer#"},
+		{RewriteNamespace, tsSymbol, "web/Ts_A", "scip-typescript npm . . web/Ts_A/protos/`Go_A.ts`/Go_AClient#"},
+		{RewriteNamespace, goSymbol, "..", "scip-go gomod Go_A v1 `..`/`Go_A/proto`/Go_AServer#"},
+		{RewritePackage, goSymbol, "Go_A", "scip-go gomod Go_A/Go_A v1 `Go_A/proto`/Go_AServer#"},
+		{RewritePackage, tsSymbol, "Ts A", "scip-typescript npm Ts  A . protos/`Go_A.ts`/Go_AClient#"},
+		{RewriteVersion, goSymbol, "Go_A", "scip-go gomod Go_A Go_A/v1 `Go_A/proto`/Go_AServer#"},
+		{RewriteVersion, tsSymbol, "Ts_A", "scip-typescript npm . Ts_A protos/`Go_A.ts`/Go_AClient#"},
+		{RewriteNone, goSymbol, "Go_A", goSymbol},
+		{RewriteNamespace, goSymbol, "", goSymbol},
+		{RewriteNamespace, "local 12", "Go_A", "local 12"},
+		{RewritePackage, "local 12", "Go_A", "local 12"},
+	}
+	for _, test := range tests {
+		rewrite = test.rewrite
+		got := rewriteSymbol(test.symbol, test.namespace)
+		if got != test.want {
+			t.Errorf("%s rewrite of %q in %q: got %q, want %q", test.rewrite, test.symbol, test.namespace, got, test.want)
+		}
+		if _, err := scip.ParseSymbol(got); err != nil {
+			t.Errorf("%s rewrite of %q gave an invalid symbol: %v", test.rewrite, test.symbol, err)
+		}
+	}
+
+	if _, err := ParseRewrite("descriptor"); err == nil {
+		t.Errorf("expected an error for an unknown rewrite")
+	}
+}
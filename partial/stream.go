@@ -0,0 +1,137 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+// StreamMatch holds the symbols of a stream wrapper type matched for one side
+// of a streaming method, e.g. Go_A_Go_A_1Server for the server side of Go_A_1.
+type StreamMatch struct {
+	Method *protogen.Method
+	// Client is set for the client side of the stream.
+	Client bool
+	// Types are the symbols of the wrapper types.
+	Types []*scip.SymbolInformation
+	// Methods maps the names returned by streamMethodNames to their symbols.
+	Methods map[string][]*scip.SymbolInformation
+}
+
+// streamMethodNames returns the methods of the stream wrapper of one side of a
+// streaming method, as generated by protoc-gen-go-grpc.
+func streamMethodNames(m *protogen.Method, client bool) []string {
+	clientStreaming, serverStreaming := m.Desc.IsStreamingClient(), m.Desc.IsStreamingServer()
+	switch {
+	case clientStreaming && serverStreaming:
+		return []string{"Send", "Recv"}
+	case serverStreaming && client:
+		return []string{"Recv"}
+	case serverStreaming:
+		return []string{"Send"}
+	case clientStreaming && client:
+		return []string{"Send", "CloseAndRecv"}
+	case clientStreaming:
+		return []string{"SendAndClose", "Recv"}
+	}
+	return nil
+}
+
+func isStreaming(m *protogen.Method) bool {
+	return m.Desc.IsStreamingClient() || m.Desc.IsStreamingServer()
+}
+
+func streamSide(client bool) string {
+	if client {
+		return "Client"
+	}
+	return "Server"
+}
+
+// getStreamKey returns the key of the stream wrapper of a side of m. The #
+// keeps it apart from the keys of the methods, e.g. of an rpc named
+// <method>Server.
+func getStreamKey(m *protogen.Method, client bool) string {
+	return getMethodKey(m) + "#" + strings.ToLower(streamSide(client))
+}
+
+// generateStreams adds the symbols of the stream wrappers of a streaming
+// method, e.g. Go_A#Go_A_1.Server# and Go_A#Go_A_1.Server#Send. for a server
+// streaming method.
+func generateStreams(f *protogen.File, m *protogen.Method, d *scip.Document, siMap map[string]*scip.SymbolInformation) {
+	if !isStreaming(m) {
+		return
+	}
+	for _, client := range []bool{false, true} {
+		key := getStreamKey(m, client)
+		for _, name := range append([]string{""}, streamMethodNames(m, client)...) {
+			symbol := makeStreamSymbol(f, m, client, name)
+			kind := scip.SymbolInformation_Method
+			if name == "" {
+				kind = scip.SymbolInformation_Interface
+			}
+			siMap[key+name] = makeSymbolInformation(symbol, kind)
+			d.Symbols = append(d.Symbols, siMap[key+name])
+			d.Occurrences = append(d.Occurrences, makeDefinition(f, m.Location.Path, symbol))
+		}
+	}
+}
+
+func makeStreamSymbol(f *protogen.File, method *protogen.Method, client bool, member string) string {
+	sym, err := scip.ParseSymbol(makeMethodSymbol(f, method))
+	if err != nil {
+		return ""
+	}
+	sym.Descriptors = append(sym.Descriptors, &scip.Descriptor{Name: streamSide(client), Suffix: scip.Descriptor_Type})
+	if member != "" {
+		sym.Descriptors = append(sym.Descriptors, &scip.Descriptor{Name: member, Suffix: scip.Descriptor_Term})
+	}
+	return scip.VerboseSymbolFormatter.FormatSymbol(sym)
+}
+
+// addStreamSymbols maps the symbols of the matched stream wrappers to the keys
+// of their proto symbols.
+func addStreamSymbols(streams []*StreamMatch, siMap map[*scip.SymbolInformation]string) {
+	for _, stream := range streams {
+		key := getStreamKey(stream.Method, stream.Client)
+		for _, si := range stream.Types {
+			siMap[si] = key
+		}
+		for name, matches := range stream.Methods {
+			for _, si := range matches {
+				siMap[si] = key + name
+			}
+		}
+	}
+}
+
+// matchGoStream matches the stream wrappers generated by protoc-gen-go-grpc,
+// i.e. the <Service>_<Method>Server and <Service>_<Method>Client interfaces
+// and the types implementing them.
+func matchGoStream(s *protogen.Service, t *ScipType) *MatchResult {
+	for _, m := range s.Methods {
+		if !isStreaming(m) {
+			continue
+		}
+		for _, client := range []bool{false, true} {
+			name := s.GoName + "_" + m.GoName + streamSide(client)
+			if t.ShortName() != name && !implementsType(t.TypeSymbol, namedOneOf([]string{name})) {
+				continue
+			}
+			stream := &StreamMatch{Method: m, Client: client, Types: []*scip.SymbolInformation{t.TypeSymbol}, Methods: map[string][]*scip.SymbolInformation{}}
+			for _, method := range streamMethodNames(m, client) {
+				if matches := t.findMembers(method); len(matches) > 0 {
+					stream.Methods[method] = matches
+				}
+			}
+			return &MatchResult{
+				Methods: map[*protogen.Method][]*scip.SymbolInformation{},
+				Streams: []*StreamMatch{stream},
+				Score:   2,
+				Client:  client,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package partial
+
+import (
+	"protoc-gen-scip/scip"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestStreams(t *testing.T) {
+	// Go_A_1Server is a unary method named as the server stream of Go_A_1.
+	fd := newTestFileDescriptor("Go_A", "Go_A_1", "Go_A_2", "Go_A_1Server")
+	fd.Service[0].Method[0].ServerStreaming = proto.Bool(true)
+	f := newTestFileFromDescriptor(t, fd)
+	s := f.Services[0]
+
+	symbols := generateService(f, s, &scip.Document{})
+	serverKey, clientKey := getStreamKey(s.Methods[0], false), getStreamKey(s.Methods[0], true)
+	for _, key := range []string{serverKey, serverKey + "Send", clientKey, clientKey + "Recv"} {
+		if symbols[key] == nil {
+			t.Errorf("expected a symbol for %s", key)
+		}
+	}
+	if symbols[getStreamKey(s.Methods[1], false)] != nil {
+		t.Errorf("expected no stream symbol for the unary method")
+	}
+	if got, want := symbols[serverKey+"Send"].Symbol, "scip-proto proto protos proto3 proto/Go_A/Go_A#Go_A_1.Server#Send."; got != want {
+		t.Errorf("got symbol %s, want %s", got, want)
+	}
+	if got, want := symbols[getMethodKey(s.Methods[2])].Symbol, "scip-proto proto protos proto3 proto/Go_A/Go_A#Go_A_1Server."; got != want {
+		t.Errorf("expected the method Go_A_1Server to keep its symbol, got %s", got)
+	}
+
+	client := newTestType(t,
+		goPackage+"Go_A_Go_A_1Client#",
+		goPackage+"Go_A_Go_A_1Client#Recv.",
+	)
+	res := goGrpcMatcher{}.Match(s, client)
+	if res == nil || len(res.Streams) != 1 || !res.Client {
+		t.Fatalf("expected Go_A_Go_A_1Client to match as a client stream, got %v", res)
+	}
+	if stream := res.Streams[0]; stream.Method != s.Methods[0] || len(stream.Methods["Recv"]) != 1 {
+		t.Errorf("expected Recv of Go_A_1 to match, got %v", stream)
+	}
+}
@@ -0,0 +1,225 @@
+package partial
+
+import (
+	"path"
+	"protoc-gen-scip/scip"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+func init() {
+	RegisterMatcher("ts-grpc", tsGrpcMatcher{})
+}
+
+// tsGrpcMatcher matches the code generated for @grpc/grpc-js and connect-es and
+// indexed by scip-typescript, that is
+//   - the <Service>Server, <Service>Client and <Service>Service types
+//     generated by ts-proto, and their I<Service>Server, I<Service>Client and
+//     I<Service>Service counterparts generated by grpc_tools_node_protoc_ts,
+//   - the classes implementing one of the types above,
+//   - the <Service>Service, <Service>Client and <Service> constants describing
+//     the service, whose methods are the properties of an object literal.
+//
+// Depending on the generator, the method names are kept as is, have their
+// first letter lower cased (go_A_1) or are camel cased (goA1).
+type tsGrpcMatcher struct{}
+
+func (tsGrpcMatcher) Match(s *protogen.Service, t *ScipType) *MatchResult {
+	if !strings.HasPrefix(t.TypeSymbol.Symbol, "scip-typescript ") {
+		return nil
+	}
+	name := string(s.Desc.Name())
+
+	if t.Module {
+		return matchTsServiceDefinition(s, t)
+	}
+
+	names := tsServiceTypeNames(name)
+	if !stringSliceContains(names, t.ShortName()) && !implementsType(t.TypeSymbol, namedOneOf(names)) {
+		return nil
+	}
+	res := &MatchResult{
+		Methods: map[*protogen.Method][]*scip.SymbolInformation{},
+		Score:   2,
+		Client:  t.ShortName() == name+"Client" || t.ShortName() == "I"+name+"Client",
+	}
+	for _, m := range s.Methods {
+		for _, methodName := range tsMethodNames(string(m.Desc.Name())) {
+			res.Methods[m] = append(res.Methods[m], t.findMembers(methodName)...)
+		}
+		if len(res.Methods[m]) == 0 {
+			delete(res.Methods, m)
+		}
+	}
+	return res
+}
+
+// matchTsServiceDefinition matches the constants describing the service in the
+// module t. The properties of the object literals are recorded by
+// scip-typescript as top level symbols suffixed with a counter, e.g. goA10:.
+func matchTsServiceDefinition(s *protogen.Service, t *ScipType) *MatchResult {
+	name := string(s.Desc.Name())
+	res := &MatchResult{Methods: map[*protogen.Method][]*scip.SymbolInformation{}, Score: 2}
+	res.Service = append(t.findMembers(name+"Service"), t.findMembers(name+"Client")...)
+	res.Service = append(res.Service, t.findMembers(name)...)
+	if len(res.Service) == 0 {
+		return nil
+	}
+
+	for idx, member := range t.Methods {
+		si := t.MethodSymbols[idx]
+		if !strings.HasSuffix(si.Symbol, ":") {
+			continue
+		}
+		// the longest method name wins, e.g. goA11 for goA110:
+		var best *protogen.Method
+		bestLen := 0
+		for _, m := range s.Methods {
+			for _, methodName := range tsMethodNames(string(m.Desc.Name())) {
+				if len(methodName) > bestLen && isCounterSuffixed(member, methodName) {
+					best, bestLen = m, len(methodName)
+				}
+			}
+		}
+		if best != nil {
+			res.Methods[best] = append(res.Methods[best], si)
+		}
+	}
+	if len(res.Methods) == 0 {
+		return nil
+	}
+	return res
+}
+
+// tsGeneratedFileSuffixes are appended to the name of the proto file by the
+// TypeScript generators, before the extension.
+var tsGeneratedFileSuffixes = []string{"", "_pb", "_grpc_pb", "_grpc", "_connect"}
+
+// InScope matches the files generated next to each other for f, e.g.
+// protos/Go_A.ts or protos/Go_A_grpc_pb.d.ts for protos/Go_A.proto.
+func (tsGrpcMatcher) InScope(f *protogen.File, scope string) bool {
+	return tsFileInScope(f.Desc.Path(), scope)
+}
+
+func tsFileInScope(protoPath string, scope string) bool {
+	name := strings.TrimSuffix(protoPath, ".proto")
+	dir, base := path.Split(name)
+	idx := strings.LastIndex(scope, "/")
+	if dir != "" && !hasPathSuffix(scope[:idx+1], dir) {
+		return false
+	}
+	file := scope[idx+1:]
+	if dot := strings.Index(file, "."); dot >= 0 {
+		file = file[:dot]
+	}
+	for _, suffix := range tsGeneratedFileSuffixes {
+		if file == base+suffix {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchMessage matches the interface generated by ts-proto for m and its
+// fields. The nested messages are prefixed with the names of their enclosing
+// messages, e.g. CommonMessage_MyMapEntry.
+func (tsGrpcMatcher) MatchMessage(m *protogen.Message, t *ScipType) *MessageMatchResult {
+	if t.Module || !strings.HasPrefix(t.TypeSymbol.Symbol, "scip-typescript ") || t.ShortName() != relativeMessageName(m, "_") || !tsFileInScope(m.Desc.ParentFile().Path(), t.Scope()) {
+		return nil
+	}
+	res := &MessageMatchResult{Fields: map[*protogen.Field][]*scip.SymbolInformation{}, Score: 2}
+	for _, field := range m.Fields {
+		names := []string{field.Desc.JSONName()}
+		if name := string(field.Desc.Name()); name != names[0] {
+			names = append(names, name)
+		}
+		for _, name := range names {
+			res.Fields[field] = append(res.Fields[field], t.findMembers(name)...)
+		}
+		if len(res.Fields[field]) == 0 {
+			delete(res.Fields, field)
+		}
+	}
+	return res
+}
+
+// tsServiceTypeNames returns the names of the types generated for a service.
+func tsServiceTypeNames(service string) []string {
+	return []string{
+		service + "Server",
+		service + "Client",
+		service + "Service",
+		"I" + service + "Server",
+		"I" + service + "Client",
+		"I" + service + "Service",
+	}
+}
+
+// tsMethodNames returns the names a method can be given by the TypeScript
+// generators.
+func tsMethodNames(method string) []string {
+	names := []string{method}
+	for _, name := range []string{lowerFirst(method), tsCamelCase(method)} {
+		if !stringSliceContains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// tsCamelCase converts a method name as ts-proto and connect-es do, e.g.
+// Go_A_1 becomes goA1.
+func tsCamelCase(s string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(s, "_") {
+		if i == 0 {
+			b.WriteString(lowerFirst(part))
+		} else if part != "" {
+			b.WriteString(upperFirst(part))
+		}
+	}
+	return b.String()
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// isCounterSuffixed reports whether s is prefix followed by a non empty
+// decimal counter.
+func isCounterSuffixed(s string, prefix string) bool {
+	if !strings.HasPrefix(s, prefix) || len(s) == len(prefix) {
+		return false
+	}
+	for _, r := range s[len(prefix):] {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func stringSliceContains(slice []string, target string) bool {
+	for _, candidate := range slice {
+		if target == candidate {
+			return true
+		}
+	}
+	return false
+}
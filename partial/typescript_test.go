@@ -0,0 +1,76 @@
+package partial
+
+import "testing"
+
+const tsPackage = "scip-typescript npm . . "
+
+func TestTsMethodNames(t *testing.T) {
+	tests := map[string][]string{
+		"Go_A_1":   {"Go_A_1", "go_A_1", "goA1"},
+		"SayHello": {"SayHello", "sayHello"},
+		"sayHello": {"sayHello"},
+	}
+	for method, want := range tests {
+		got := tsMethodNames(method)
+		if len(got) != len(want) {
+			t.Errorf("tsMethodNames(%q) = %v, want %v", method, got, want)
+			continue
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("tsMethodNames(%q) = %v, want %v", method, got, want)
+			}
+		}
+	}
+}
+
+func TestTsGrpcMatcher(t *testing.T) {
+	f := newTestFile(t, "Ts_A", "Ts_A_1", "Ts_A_11")
+	s := f.Services[0]
+
+	tests := []struct {
+		name    string
+		symbols []string
+	}{
+		{
+			name: "ts-proto server",
+			symbols: []string{
+				tsPackage + "Ts_A/protos/`Ts_A.ts`/Ts_AServer#",
+				tsPackage + "Ts_A/protos/`Ts_A.ts`/Ts_AServer#tsA1.",
+				tsPackage + "Ts_A/protos/`Ts_A.ts`/Ts_AServer#tsA11.",
+			},
+		},
+		{
+			name: "grpc-js client",
+			symbols: []string{
+				tsPackage + "Ts_A/protos/`Ts_A_grpc_pb.d.ts`/Ts_AClient#",
+				tsPackage + "Ts_A/protos/`Ts_A_grpc_pb.d.ts`/Ts_AClient#ts_A_1().",
+				tsPackage + "Ts_A/protos/`Ts_A_grpc_pb.d.ts`/Ts_AClient#ts_A_1().(request)",
+				tsPackage + "Ts_A/protos/`Ts_A_grpc_pb.d.ts`/Ts_AClient#ts_A_11().",
+			},
+		},
+		{
+			name: "object literal",
+			symbols: []string{
+				tsPackage + "Ts_A/protos/`Ts_A.ts`/",
+				tsPackage + "Ts_A/protos/`Ts_A.ts`/Ts_AService.",
+				tsPackage + "Ts_A/protos/`Ts_A.ts`/tsA10:",
+				tsPackage + "Ts_A/protos/`Ts_A.ts`/path0:",
+				tsPackage + "Ts_A/protos/`Ts_A.ts`/tsA110:",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tsGrpcMatcher{}.Match(s, newTestType(t, tt.symbols...))
+			if res == nil {
+				t.Fatalf("expected a match")
+			}
+			for _, m := range s.Methods {
+				if len(res.Methods[m]) != 1 {
+					t.Errorf("expected a single symbol for %s, got %v", m.GoName, res.Methods[m])
+				}
+			}
+		})
+	}
+}